@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	studentAttendanceAuditResource  = "student_attendance"
+	lecturerAttendanceAuditResource = "lecturer_attendance"
+)
+
+type (
+	// PresensiStatus adalah kolom yang sama pada presensi_mahasiswa dan presensi_dosen.
+	PresensiStatus struct {
+		Status     string     `gorm:"column:status;type:enum('hadir','izin','sakit','alpa');not null" json:"status"`
+		Keterangan *string    `gorm:"column:keterangan;type:text" json:"keterangan"`
+		CreatedAt  *time.Time `gorm:"column:created_at" json:"created_at"`
+		UpdatedAt  *time.Time `gorm:"column:updated_at" json:"updated_at"`
+	}
+
+	// PresensiMahasiswa adalah presensi satu mahasiswa pada satu sesi jadwal_perkuliahan.
+	PresensiMahasiswa struct {
+		ID                  int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+		IDJadwalPerkuliahan int64  `gorm:"column:id_jadwal_perkuliahan" json:"id_jadwal_perkuliahan"`
+		IDPesertaDidik      string `gorm:"column:id_pd" json:"id_pd"`
+		PresensiStatus
+	}
+
+	// PresensiDosen adalah presensi satu dosen pengajar pada satu sesi jadwal_perkuliahan.
+	PresensiDosen struct {
+		ID                  int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+		IDJadwalPerkuliahan int64  `gorm:"column:id_jadwal_perkuliahan" json:"id_jadwal_perkuliahan"`
+		IDPTK               string `gorm:"column:id_ptk" json:"id_ptk"`
+		PresensiStatus
+	}
+
+	SubmitAttendanceRequest struct {
+		Mahasiswa []StudentAttendanceInput  `json:"mahasiswa" validate:"dive"`
+		Dosen     []LecturerAttendanceInput `json:"dosen" validate:"dive"`
+	}
+
+	StudentAttendanceInput struct {
+		IDPesertaDidik string  `json:"id_pd" validate:"required"`
+		Status         string  `json:"status" validate:"required,oneof=hadir izin sakit alpa"`
+		Keterangan     *string `json:"keterangan"`
+	}
+
+	LecturerAttendanceInput struct {
+		IDPTK      string  `json:"id_ptk" validate:"required"`
+		Status     string  `json:"status" validate:"required,oneof=hadir izin sakit alpa"`
+		Keterangan *string `json:"keterangan"`
+	}
+
+	AttendanceSummary struct {
+		Dibuat int `json:"dibuat"`
+		Diubah int `json:"diubah"`
+		Tetap  int `json:"tetap"`
+	}
+
+	SubmitAttendanceResponse struct {
+		IDJadwalPerkuliahan int64             `json:"id_jadwal_perkuliahan"`
+		IDKelas             int64             `json:"id_kls"`
+		Sesi                int64             `json:"sesi"`
+		Tanggal             string            `json:"tanggal"`
+		Mahasiswa           AttendanceSummary `json:"mahasiswa"`
+		Dosen               AttendanceSummary `json:"dosen"`
+	}
+)
+
+var (
+	ErrAttendanceSmart     = errors.New("presensi per sesi hanya tersedia untuk instansi Misca")
+	ErrAttendanceEmpty     = errors.New("mahasiswa atau dosen wajib diisi")
+	ErrDuplicateAttendance = errors.New("data presensi dikirim lebih dari sekali")
+	ErrStudentNotEnrolled  = errors.New("mahasiswa tidak terdaftar pada kelas ini")
+	ErrLecturerNotAssigned = errors.New("dosen tidak mengajar pada kelas ini")
+)
+
+func (PresensiMahasiswa) TableName() string {
+	return "presensi_mahasiswa"
+}
+
+func (PresensiDosen) TableName() string {
+	return "presensi_dosen"
+}
+
+// FindJadwalPerkuliahanMisca mengambil satu sesi beserta semester kelasnya.
+func (a *ApplicationServer) FindJadwalPerkuliahanMisca(id string) (*JadwalPerkuliahan, string, error) {
+	var sesi JadwalPerkuliahan
+	err := a.db.Where("id = ?", id).Take(&sesi).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", NewNotFoundError("Sesi perkuliahan tidak ditemukan")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var semester string
+	if err := a.db.Table("kelaskuliah").Select("id_smt").Where("id_kls = ?", sesi.IDKls).Scan(&semester).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &sesi, semester, nil
+}
+
+// SubmitSessionAttendanceMisca menyimpan presensi mahasiswa dan dosen untuk satu sesi.
+// Presensi unik per sesi dan peserta (unique index pada migrations/003) dan ditulis dengan upsert,
+// sehingga pengiriman ulang maupun request bersamaan mengubah baris yang sama, bukan menambah baris.
+func (a *ApplicationServer) SubmitSessionAttendanceMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return HandleError(c, NewNotImplementedError(ErrAttendanceSmart))
+	}
+
+	req := new(SubmitAttendanceRequest)
+	if err := c.BodyParser(req); err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if len(req.Mahasiswa) == 0 && len(req.Dosen) == 0 {
+		return HandleError(c, NewBadRequestError(ErrAttendanceEmpty))
+	}
+
+	studentIDs, err := uniqueAttendanceIDs(req.Mahasiswa, func(s StudentAttendanceInput) string { return s.IDPesertaDidik })
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	lecturerIDs, err := uniqueAttendanceIDs(req.Dosen, func(d LecturerAttendanceInput) string { return d.IDPTK })
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	sesi, semester, err := a.FindJadwalPerkuliahanMisca(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := a.validateAttendanceParticipants(sesi.IDKls, semester, studentIDs, lecturerIDs); err != nil {
+		return HandleError(c, err)
+	}
+
+	response := SubmitAttendanceResponse{
+		IDJadwalPerkuliahan: sesi.ID,
+		IDKelas:             sesi.IDKls,
+		Sesi:                sesi.Sesi,
+		Tanggal:             sesi.Tanggal.Format(time.DateOnly),
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		for _, input := range req.Mahasiswa {
+			var presensi PresensiMahasiswa
+			err := tx.Where("id_jadwal_perkuliahan = ? AND id_pd = ?", sesi.ID, input.IDPesertaDidik).Limit(1).Find(&presensi).Error
+			if err != nil {
+				return err
+			}
+
+			before := presensi
+			presensi.IDJadwalPerkuliahan = sesi.ID
+			presensi.IDPesertaDidik = input.IDPesertaDidik
+
+			action := presensi.apply(presensi.ID != 0, input.Status, input.Keterangan, now)
+			if action != "" {
+				if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&presensi).Error; err != nil {
+					return err
+				}
+			}
+
+			recordID := fmt.Sprintf("%d:%s", sesi.ID, input.IDPesertaDidik)
+			if err := auditAttendance(c, tx, studentAttendanceAuditResource, recordID, action, before, presensi, &response.Mahasiswa); err != nil {
+				return err
+			}
+		}
+
+		for _, input := range req.Dosen {
+			var presensi PresensiDosen
+			err := tx.Where("id_jadwal_perkuliahan = ? AND id_ptk = ?", sesi.ID, input.IDPTK).Limit(1).Find(&presensi).Error
+			if err != nil {
+				return err
+			}
+
+			before := presensi
+			presensi.IDJadwalPerkuliahan = sesi.ID
+			presensi.IDPTK = input.IDPTK
+
+			action := presensi.apply(presensi.ID != 0, input.Status, input.Keterangan, now)
+			if action != "" {
+				if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&presensi).Error; err != nil {
+					return err
+				}
+			}
+
+			recordID := fmt.Sprintf("%d:%s", sesi.ID, input.IDPTK)
+			if err := auditAttendance(c, tx, lecturerAttendanceAuditResource, recordID, action, before, presensi, &response.Dosen); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[SubmitAttendanceResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses menyimpan data presensi",
+		Data:    response,
+	})
+}
+
+// apply mengisi status dan keterangan, mengembalikan string kosong jika tidak ada perubahan.
+func (p *PresensiStatus) apply(exists bool, status string, keterangan *string, now time.Time) string {
+	if exists && p.Status == status && equalPtr(p.Keterangan, keterangan) {
+		return ""
+	}
+
+	action := auditActionUpdate
+	if !exists {
+		action = auditActionCreate
+		p.CreatedAt = &now
+	}
+
+	p.Status = status
+	p.Keterangan = keterangan
+	p.UpdatedAt = &now
+
+	return action
+}
+
+func auditAttendance(c *fiber.Ctx, tx *gorm.DB, resource, recordID, action string, before, after interface{}, summary *AttendanceSummary) error {
+	switch action {
+	case auditActionCreate:
+		summary.Dibuat++
+		return writeAuditLog(c, tx, resource, recordID, action, nil, after)
+	case auditActionUpdate:
+		summary.Diubah++
+		return writeAuditLog(c, tx, resource, recordID, action, before, after)
+	default:
+		summary.Tetap++
+		return nil
+	}
+}
+
+// validateAttendanceParticipants memastikan mahasiswa terdaftar pada kelas dan semester tersebut (tabel nilai)
+// dan dosen tercatat sebagai pengajar kelas (akt_mengajar_dosen).
+func (a *ApplicationServer) validateAttendanceParticipants(idKelas int64, semester string, studentIDs, lecturerIDs []string) error {
+	invalid := make([]string, 0)
+
+	if len(studentIDs) > 0 {
+		enrolled := make([]string, 0)
+		err := a.db.Table("nilai").
+			Where("id_kls = ? AND smt_ambil = ? AND id_pd IN ?", idKelas, semester, studentIDs).
+			Distinct().
+			Pluck("id_pd", &enrolled).
+			Error
+		if err != nil {
+			return err
+		}
+
+		invalid = append(invalid, missingIDs(studentIDs, enrolled, ErrStudentNotEnrolled)...)
+	}
+
+	if len(lecturerIDs) > 0 {
+		assigned := make([]string, 0)
+		err := a.db.Table("akt_mengajar_dosen").
+			Where("id_kls = ? AND id_ptk IN ?", idKelas, lecturerIDs).
+			Distinct().
+			Pluck("CAST(id_ptk AS CHAR)", &assigned).
+			Error
+		if err != nil {
+			return err
+		}
+
+		invalid = append(invalid, missingIDs(lecturerIDs, assigned, ErrLecturerNotAssigned)...)
+	}
+
+	if len(invalid) > 0 {
+		info := strings.Join(invalid, ", ")
+		return NewAppError(http.StatusUnprocessableEntity, "Presensi tidak bisa disimpan: "+info, errors.New(info))
+	}
+
+	return nil
+}
+
+func missingIDs(ids, found []string, reason error) []string {
+	foundMap := make(map[string]struct{}, len(found))
+	for _, id := range found {
+		foundMap[id] = struct{}{}
+	}
+
+	missing := make([]string, 0)
+	for _, id := range ids {
+		if _, ok := foundMap[id]; !ok {
+			missing = append(missing, fmt.Sprintf("%s: %s", id, reason.Error()))
+		}
+	}
+
+	return missing
+}
+
+func uniqueAttendanceIDs[T any](items []T, id func(T) string) ([]string, error) {
+	ids := make([]string, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+
+	for _, item := range items {
+		key := id(item)
+		if _, ok := seen[key]; ok {
+			return nil, NewBadRequestError(errors.Wrap(ErrDuplicateAttendance, key))
+		}
+		seen[key] = struct{}{}
+		ids = append(ids, key)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	auditActionCreate = "create"
+	auditActionUpdate = "update"
+)
+
+// AuditLog mencatat setiap perubahan data yang dilakukan melalui connector.
+// Before dan After berisi json data sebelum dan sesudah perubahan (null untuk data baru).
+type AuditLog struct {
+	ID        int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	Resource  string     `gorm:"column:resource;type:varchar(64);index:idx_audit_resource"`
+	RecordID  string     `gorm:"column:record_id;type:varchar(191);index:idx_audit_resource"`
+	Action    string     `gorm:"column:action;type:varchar(32)"`
+	Before    *string    `gorm:"column:before;type:longtext"`
+	After     *string    `gorm:"column:after;type:longtext"`
+	ApiKey    string     `gorm:"column:api_key;type:varchar(32)"`
+	Instansi  string     `gorm:"column:instansi;type:varchar(16)"`
+	IPAddress string     `gorm:"column:ip_address;type:varchar(64)"`
+	CreatedAt *time.Time `gorm:"column:created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "connector_audit_log"
+}
+
+// writeAuditLog dipanggil di dalam transaksi yang sama dengan perubahan data,
+// sehingga perubahan tanpa audit log tidak akan tersimpan.
+func writeAuditLog(c *fiber.Ctx, tx *gorm.DB, resource, recordID, action string, before, after interface{}) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	instansi := instansiTypeMisca
+	if IsSmartInstansi(c) {
+		instansi = instansiTypeSmart
+	}
+
+	fingerprint, _ := c.Locals(apiKeyFingerprintKey).(string)
+	now := time.Now()
+
+	return tx.Create(&AuditLog{
+		Resource:  resource,
+		RecordID:  recordID,
+		Action:    action,
+		Before:    beforeJSON,
+		After:     afterJSON,
+		ApiKey:    fingerprint,
+		Instansi:  instansi,
+		IPAddress: c.IP(),
+		CreatedAt: &now,
+	}).Error
+}
+
+func auditJSON(value interface{}) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	s := string(b)
+	return &s, nil
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	calendarContentType = "text/calendar; charset=utf-8"
+	calendarUIDDomain   = "g-learning-connector"
+)
+
+type (
+	CalendarFeedRequest struct {
+		Semester string `json:"semester" form:"semester" query:"semester"`
+	}
+
+	// calendarSchedule adalah baris jadwal mingguan atau sesi jadwal_perkuliahan beserta nama kelas dan ruangan.
+	calendarSchedule struct {
+		ID                 *int64     `gorm:"column:id"`
+		IDKelas            string     `gorm:"column:id_kelas"`
+		NamaKelas          string     `gorm:"column:nama_kelas"`
+		NamaMataKuliah     string     `gorm:"column:nama_matakuliah"`
+		Hari               int        `gorm:"column:hari"`
+		Tanggal            *time.Time `gorm:"column:tanggal"`
+		Sesi               *int64     `gorm:"column:sesi"`
+		MetodePembelajaran *string    `gorm:"column:metode_pembelajaran"`
+		JenisPertemuan     *string    `gorm:"column:jenis_pertemuan"`
+		JamMulai           string     `gorm:"column:jam_mulai"`
+		JamSelesai         string     `gorm:"column:jam_selesai"`
+		NamaRuangan        *string    `gorm:"column:nama_ruangan"`
+		URL                *string    `gorm:"column:url"`
+	}
+
+	semesterPeriod struct {
+		IDSemester     string     `gorm:"column:id_smt"`
+		TanggalMulai   *time.Time `gorm:"column:tgl_mulai"`
+		TanggalSelesai *time.Time `gorm:"column:tgl_selesai"`
+	}
+
+	// calendarSource membedakan tabel kelas, mata kuliah, dosen pengajar dan peserta pada skema Misca dan Smart.
+	calendarSource struct {
+		activeSemester func() (string, error)
+		kelasTable     string
+		matkulJoins    []string
+		matkulName     string
+		roomNameColumn string
+		ajarTable      string
+		ptkColumn      string
+		// studentClasses adalah subquery id_kls yang diambil mahasiswa, dengan satu parameter id mahasiswa
+		studentClasses string
+		// jadwal_perkuliahan hanya tersedia pada Misca
+		sessions bool
+	}
+)
+
+func (a *ApplicationServer) calendarSourceMisca() calendarSource {
+	return calendarSource{
+		activeSemester: a.getActiveSemesterIDMisca,
+		kelasTable:     "kelaskuliah",
+		matkulJoins: []string{
+			"LEFT JOIN matakuliah_kurikulum ON matakuliah_kurikulum.id_mk_kur = kelaskuliah.id_mk_kur",
+			"LEFT JOIN matakuliah ON matakuliah.id_mk = matakuliah_kurikulum.id_mk",
+		},
+		matkulName:     "matakuliah.nm_mk",
+		roomNameColumn: "ruangan.nama_ruangan",
+		ajarTable:      "akt_mengajar_dosen",
+		ptkColumn:      "id_ptk",
+		studentClasses: `SELECT nilai.id_kls FROM nilai
+			JOIN mahasiswa_histori ON mahasiswa_histori.id_pd = nilai.id_pd
+			WHERE mahasiswa_histori.id_mahasiswa = ?`,
+		sessions: true,
+	}
+}
+
+func (a *ApplicationServer) calendarSourceSmart() calendarSource {
+	return calendarSource{
+		activeSemester: a.getActiveSemesterIDSmart,
+		kelasTable:     "kelas_kuliah",
+		matkulJoins:    []string{"LEFT JOIN matkul ON matkul.id_mk = kelas_kuliah.id_mk"},
+		matkulName:     "matkul.nm_mk",
+		roomNameColumn: "ruangan.kode_ruangan",
+		ajarTable:      "akt_ajar_dosen",
+		ptkColumn:      "id_reg_ptk",
+		studentClasses: "SELECT nilai.id_kls FROM nilai WHERE nilai.id_reg_pd = ?",
+	}
+}
+
+// CalendarFeedMisca mengembalikan feed .ics satu mahasiswa, dosen, ruangan, atau kelas pada semester
+// (default semester aktif). Sesi jadwal_perkuliahan menjadi event tunggal, sedangkan jadwal mingguan menjadi
+// event berulang selama semester untuk kelas yang belum memiliki sesi, agar tidak tampil dua kali.
+func (a *ApplicationServer) CalendarFeedMisca(jenis string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		src := a.calendarSourceMisca()
+		if IsSmartInstansi(c) {
+			src = a.calendarSourceSmart()
+		}
+
+		req := new(CalendarFeedRequest)
+		if err := c.QueryParser(req); err != nil {
+			return HandleError(c, err)
+		}
+
+		if req.Semester == "" {
+			activeSemester, err := src.activeSemester()
+			if err != nil {
+				return HandleError(c, err)
+			}
+			req.Semester = activeSemester
+		}
+
+		start, end, err := a.findSemesterPeriod(req.Semester)
+		if err != nil {
+			return HandleError(c, err)
+		}
+
+		id := c.Params("id")
+		events := make([]gl.CalendarEvent, 0)
+
+		weekly, err := a.findCalendarSchedules(src, jenis, id, req.Semester, roomBookingSourceJadwal)
+		if err != nil {
+			return HandleError(c, err)
+		}
+
+		// event berulang berakhir pada akhir hari tanggal selesai semester
+		until := end.AddDate(0, 0, 1).Add(-time.Second)
+		for _, row := range weekly {
+			first := gl.FirstWeekday(start, row.Hari)
+			if first.After(end) {
+				continue
+			}
+
+			window, err := gl.NewSessionWindow(first, row.JamMulai, row.JamSelesai, time.Local)
+			if err != nil {
+				return HandleError(c, err)
+			}
+
+			events = append(events, gl.CalendarEvent{
+				UID:         fmt.Sprintf("jadwal-%s-%d-%s@%s", row.IDKelas, row.Hari, strings.ReplaceAll(row.JamMulai, ":", ""), calendarUIDDomain),
+				Summary:     row.summary(),
+				Description: fmt.Sprintf("Kelas %s, setiap hari %s", row.NamaKelas, gl.NamaHari(row.Hari)),
+				Location:    valueOf(row.NamaRuangan),
+				Start:       window.Start,
+				End:         window.End,
+				RecurUntil:  &until,
+			})
+		}
+
+		if src.sessions {
+			sessions, err := a.findCalendarSchedules(src, jenis, id, req.Semester, roomBookingSourceSession)
+			if err != nil {
+				return HandleError(c, err)
+			}
+
+			for _, row := range sessions {
+				window, err := gl.NewSessionWindow(*row.Tanggal, row.JamMulai, row.JamSelesai, time.Local)
+				if err != nil {
+					return HandleError(c, err)
+				}
+
+				event := gl.CalendarEvent{
+					UID:     fmt.Sprintf("sesi-%d@%s", valueOf(row.ID), calendarUIDDomain),
+					Summary: fmt.Sprintf("%s - Pertemuan %d", row.summary(), valueOf(row.Sesi)),
+					Description: fmt.Sprintf("Kelas %s, pertemuan ke-%d (%s, %s)",
+						row.NamaKelas, valueOf(row.Sesi), valueOf(row.JenisPertemuan), valueOf(row.MetodePembelajaran)),
+					URL:   valueOf(row.URL),
+					Start: window.Start,
+					End:   window.End,
+				}
+				if valueOf(row.MetodePembelajaran) != "online" {
+					event.Location = valueOf(row.NamaRuangan)
+				}
+
+				events = append(events, event)
+			}
+		}
+
+		var buf bytes.Buffer
+		name := fmt.Sprintf("Jadwal %s %s - %s", jenis, id, req.Semester)
+		if err := gl.WriteCalendar(&buf, name, events, time.Now()); err != nil {
+			return HandleError(c, err)
+		}
+
+		c.Set(fiber.HeaderContentType, calendarContentType)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s-%s.ics"`, jenis, id))
+		return c.Status(fiber.StatusOK).Send(buf.Bytes())
+	}
+}
+
+// findCalendarSchedules mengambil jadwal mingguan (source jadwal) atau sesi (source jadwal_perkuliahan)
+// kelas pada semester yang terkait dengan mahasiswa, dosen, ruangan, atau kelas.
+func (a *ApplicationServer) findCalendarSchedules(src calendarSource, jenis, id, semester, source string) ([]calendarSchedule, error) {
+	q := a.db.Table(source).
+		Joins("JOIN "+src.kelasTable+" ON "+src.kelasTable+".id_kls = "+source+".id_kls").
+		Joins("LEFT JOIN ruangan ON ruangan.id_ruangan = "+source+".id_ruangan").
+		Where(src.kelasTable+".id_smt = ?", semester)
+	for _, join := range src.matkulJoins {
+		q = q.Joins(join)
+	}
+
+	switch jenis {
+	case calendarSubjectKelas:
+		q = q.Where(source+".id_kls = ?", id)
+	case calendarSubjectRuangan:
+		q = q.Where(source+".id_ruangan = ?", id)
+	case calendarSubjectDosen:
+		q = q.Where(source+".id_kls IN (SELECT id_kls FROM "+src.ajarTable+" WHERE "+src.ptkColumn+" = ?)", id)
+	case calendarSubjectMahasiswa:
+		q = q.Where(source+".id_kls IN ("+src.studentClasses+")", id)
+	}
+
+	columns := `
+		CAST(` + source + `.id_kls AS CHAR) AS id_kelas,
+		` + src.kelasTable + `.nm_kls AS nama_kelas,
+		` + src.matkulName + ` AS nama_matakuliah,
+		` + source + `.jam_mulai AS jam_mulai,
+		` + source + `.jam_selesai AS jam_selesai,
+		` + src.roomNameColumn + ` AS nama_ruangan`
+
+	if source == roomBookingSourceSession {
+		q = q.Select(columns + `,
+			jadwal_perkuliahan.id AS id,
+			jadwal_perkuliahan.sesi AS sesi,
+			jadwal_perkuliahan.tanggal AS tanggal,
+			jadwal_perkuliahan.metode_pembelajaran AS metode_pembelajaran,
+			jadwal_perkuliahan.jenis_pertemuan AS jenis_pertemuan,
+			jadwal_perkuliahan.url AS url
+		`).Order("jadwal_perkuliahan.tanggal ASC, jadwal_perkuliahan.jam_mulai ASC")
+
+		if jenis == calendarSubjectRuangan {
+			// sesi online tidak memakai ruangan
+			q = q.Where("jadwal_perkuliahan.metode_pembelajaran != ?", "online")
+		}
+	} else {
+		q = q.Select(columns + `,
+			jadwal.hari AS hari
+		`).Order("jadwal.hari ASC, jadwal.jam_mulai ASC")
+
+		if src.sessions {
+			q = q.Where("NOT EXISTS (SELECT 1 FROM jadwal_perkuliahan WHERE jadwal_perkuliahan.id_kls = jadwal.id_kls)")
+		}
+	}
+
+	rows := make([]calendarSchedule, 0)
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// findSemesterPeriod mengambil tgl_mulai dan tgl_selesai semester, atau perkiraannya dari id_smt jika kosong.
+func (a *ApplicationServer) findSemesterPeriod(semester string) (time.Time, time.Time, error) {
+	var period semesterPeriod
+	err := a.db.Table("semester").Select("tgl_mulai, tgl_selesai").Where("id_smt = ?", semester).Scan(&period).Error
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if period.TanggalMulai != nil && period.TanggalSelesai != nil {
+		start, end := *period.TanggalMulai, *period.TanggalSelesai
+		return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local),
+			time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+
+	start, end, err := gl.DefaultSemesterPeriod(semester, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, NewBadRequestError(err)
+	}
+
+	return start, end, nil
+}
+
+// findSemesterByDate mencari semester yang periodenya memuat date. Semester dengan tgl_mulai dan tgl_selesai
+// diutamakan, semester tanpa tanggal memakai perkiraan DefaultSemesterPeriod. Hasilnya kosong jika tidak ada
+// semester yang memuat date.
+func (a *ApplicationServer) findSemesterByDate(date time.Time) (string, error) {
+	day := date.Format(time.DateOnly)
+
+	var semester string
+	err := a.db.Table("semester").
+		Select("id_smt").
+		Where("tgl_mulai IS NOT NULL AND tgl_selesai IS NOT NULL").
+		Where("DATE(tgl_mulai) <= ? AND DATE(tgl_selesai) >= ?", day, day).
+		Order("id_smt DESC").
+		Limit(1).
+		Scan(&semester).Error
+	if err != nil || semester != "" {
+		return semester, err
+	}
+
+	// semester yang memuat date berada pada tahun ajaran yang dimulai tahun sebelumnya atau tahun yang sama
+	candidates := make([]semesterPeriod, 0)
+	err = a.db.Table("semester").
+		Select("id_smt, tgl_mulai, tgl_selesai").
+		Where("tgl_mulai IS NULL OR tgl_selesai IS NULL").
+		Where("LEFT(id_smt, 4) IN ?", []string{strconv.Itoa(date.Year() - 1), strconv.Itoa(date.Year())}).
+		Order("id_smt DESC").
+		Scan(&candidates).Error
+	if err != nil {
+		return "", err
+	}
+
+	for _, candidate := range candidates {
+		start, end, err := gl.DefaultSemesterPeriod(candidate.IDSemester, date.Location())
+		if err != nil {
+			continue
+		}
+		if !date.Before(start) && !date.After(end) {
+			return candidate.IDSemester, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (s calendarSchedule) summary() string {
+	return fmt.Sprintf("%s (%s)", s.NamaMataKuliah, s.NamaKelas)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	calendarTokenAuditResource = "calendar_tokens"
+	calendarTokenActionRevoke  = "revoke"
+
+	calendarTokenQuery = "token"
+
+	calendarSubjectMahasiswa = "mahasiswa"
+	calendarSubjectDosen     = "dosen"
+	calendarSubjectRuangan   = "ruangan"
+	calendarSubjectKelas     = "kelas"
+)
+
+// calendarFeedPaths adalah path feed .ics untuk setiap jenis token.
+var calendarFeedPaths = map[string]string{
+	calendarSubjectMahasiswa: "/api/calendar/students/",
+	calendarSubjectDosen:     "/api/calendar/lecturers/",
+	calendarSubjectRuangan:   "/api/calendar/rooms/",
+	calendarSubjectKelas:     "/api/calendar/classes/",
+}
+
+// CalendarToken memberi akses baca ke satu feed .ics tanpa api key. Token yang diberikan ke pengguna adalah
+// id + "." + HMAC-SHA256 dengan secret instansi, sehingga token tidak bisa ditebak dari isi tabel ini.
+// Token berhenti berlaku jika dicabut (revoked_at) atau jika secret api key diganti.
+type CalendarToken struct {
+	ID        string     `gorm:"column:id;primaryKey;type:varchar(32)" json:"id"`
+	Jenis     string     `gorm:"column:jenis;type:varchar(16);index:idx_calendar_token_subject" json:"jenis"`
+	SubjectID string     `gorm:"column:subject_id;type:varchar(191);index:idx_calendar_token_subject" json:"subject_id"`
+	Instansi  string     `gorm:"column:instansi;type:varchar(16)" json:"instansi"`
+	ApiKey    string     `gorm:"column:api_key;type:varchar(32)" json:"-"`
+	CreatedAt *time.Time `gorm:"column:created_at" json:"created_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+}
+
+func (CalendarToken) TableName() string {
+	return "connector_calendar_token"
+}
+
+type (
+	CreateCalendarTokenRequest struct {
+		Jenis string `json:"jenis" validate:"required,oneof=mahasiswa dosen ruangan kelas"`
+		ID    string `json:"id" validate:"required,max=191"`
+	}
+
+	CalendarTokenResponse struct {
+		CalendarToken
+		Token string `json:"token,omitempty"`
+		URL   string `json:"url,omitempty"`
+	}
+)
+
+var (
+	ErrCalendarTokenInvalid = errors.New("token kalender tidak valid")
+	ErrCalendarTokenRevoked = errors.New("token kalender sudah dicabut")
+)
+
+// CreateCalendarTokenMisca membuat token feed .ics untuk satu mahasiswa, dosen, ruangan, atau kelas.
+// Token hanya ditampilkan sekali pada response ini.
+func (a *ApplicationServer) CreateCalendarTokenMisca(c *fiber.Ctx) error {
+	req := new(CreateCalendarTokenRequest)
+	if err := c.BodyParser(req); err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := a.findCalendarSubject(IsSmartInstansi(c), req.Jenis, req.ID); err != nil {
+		return HandleError(c, err)
+	}
+
+	instansi := instansiTypeMisca
+	if IsSmartInstansi(c) {
+		instansi = instansiTypeSmart
+	}
+
+	secret, err := a.findInstansiSecret(instansi)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return HandleError(c, err)
+	}
+
+	fingerprint, _ := c.Locals(apiKeyFingerprintKey).(string)
+	now := time.Now()
+	token := CalendarToken{
+		ID:        hex.EncodeToString(id),
+		Jenis:     req.Jenis,
+		SubjectID: req.ID,
+		Instansi:  instansi,
+		ApiKey:    fingerprint,
+		CreatedAt: &now,
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&token).Error; err != nil {
+			return err
+		}
+		return writeAuditLog(c, tx, calendarTokenAuditResource, token.ID, auditActionCreate, nil, token)
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	signed := token.ID + "." + token.signature(secret)
+	return c.Status(fiber.StatusCreated).JSON(ApiResponse[CalendarTokenResponse]{
+		Code:    fiber.StatusCreated,
+		Status:  http.StatusText(fiber.StatusCreated),
+		Success: true,
+		Message: "Sukses membuat token kalender",
+		Data: CalendarTokenResponse{
+			CalendarToken: token,
+			Token:         signed,
+			URL:           calendarFeedPaths[token.Jenis] + token.SubjectID + ".ics?" + calendarTokenQuery + "=" + signed,
+		},
+	})
+}
+
+// RevokeCalendarTokenMisca mencabut token berdasarkan id (bagian token sebelum tanda titik).
+func (a *ApplicationServer) RevokeCalendarTokenMisca(c *fiber.Ctx) error {
+	var token CalendarToken
+	now := time.Now()
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ?", c.Params("id")).Take(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return NewNotFoundError("Token kalender tidak ditemukan")
+		}
+		if err != nil {
+			return err
+		}
+
+		if token.RevokedAt != nil {
+			return nil
+		}
+
+		before := token
+		token.RevokedAt = &now
+		if err := tx.Model(&CalendarToken{}).Where("id = ?", token.ID).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return writeAuditLog(c, tx, calendarTokenAuditResource, token.ID, calendarTokenActionRevoke, before, token)
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[CalendarTokenResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mencabut token kalender",
+		Data:    CalendarTokenResponse{CalendarToken: token},
+	})
+}
+
+// WithCalendarToken menggantikan WithApiKey pada feed .ics. Token dari query ?token= harus belum dicabut,
+// tanda tangannya cocok dengan secret instansi, dan jenis serta id-nya sama dengan feed yang diminta.
+func (a *ApplicationServer) WithCalendarToken(jenis string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, signature, ok := strings.Cut(ctx.Query(calendarTokenQuery), ".")
+		if !ok {
+			return calendarTokenError(ctx, http.StatusUnauthorized, ErrCalendarTokenInvalid)
+		}
+
+		var token CalendarToken
+		if err := a.db.Where("id = ?", id).Limit(1).Find(&token).Error; err != nil {
+			return HandleError(ctx, err)
+		}
+
+		if token.ID == "" || token.Jenis != jenis || token.SubjectID != ctx.Params("id") {
+			return calendarTokenError(ctx, http.StatusUnauthorized, ErrCalendarTokenInvalid)
+		}
+
+		secret, err := a.findInstansiSecret(token.Instansi)
+		if err != nil {
+			return HandleError(ctx, err)
+		}
+
+		if !hmac.Equal([]byte(signature), []byte(token.signature(secret))) {
+			return calendarTokenError(ctx, http.StatusUnauthorized, ErrCalendarTokenInvalid)
+		}
+
+		if token.RevokedAt != nil {
+			return calendarTokenError(ctx, http.StatusForbidden, ErrCalendarTokenRevoked)
+		}
+
+		ctx.Locals(instansiTypeKey, token.Instansi)
+		return ctx.Next()
+	}
+}
+
+func (t CalendarToken) signature(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t.ID + "|" + t.Instansi + "|" + t.Jenis + "|" + t.SubjectID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// findInstansiSecret mengambil secret api key baca, dari setting_pt untuk Misca dan setting_app untuk Smart.
+func (a *ApplicationServer) findInstansiSecret(instansi string) (string, error) {
+	settingTable := "setting_pt"
+	if instansi == instansiTypeSmart {
+		settingTable = "setting_app"
+	}
+
+	var secret string
+	err := a.db.Table(settingTable).Where("param = ?", secretParam).Select("value").Scan(&secret).Error
+	if err != nil {
+		return "", err
+	}
+
+	if secret == "" {
+		return "", NewAppError(http.StatusUnauthorized, "Secret tidak ditemukan", ErrCalendarTokenInvalid)
+	}
+
+	return secret, nil
+}
+
+// findCalendarSubject memastikan mahasiswa, dosen, ruangan, atau kelas yang akan dibuatkan token ada.
+func (a *ApplicationServer) findCalendarSubject(smart bool, jenis, id string) error {
+	var q *gorm.DB
+	var notFound string
+
+	switch jenis {
+	case calendarSubjectMahasiswa:
+		q, notFound = a.db.Table("mahasiswa").Where("id = ? AND deleted_at IS NULL", id), "Mahasiswa tidak ditemukan"
+		if smart {
+			q = a.db.Table("mahasiswa").Where("id_pd = ?", id)
+		}
+	case calendarSubjectDosen:
+		q, notFound = a.db.Table("dosen").Where("id_ptk = ?", id), "Dosen tidak ditemukan"
+		if smart {
+			// feed Smart memfilter akt_ajar_dosen.id_reg_ptk, bukan dosen.id_ptk
+			q = a.db.Table("akt_ajar_dosen").Where("id_reg_ptk = ?", id)
+		}
+	case calendarSubjectRuangan:
+		q, notFound = a.db.Table("ruangan").Where("id_ruangan = ?", id), "Ruangan tidak ditemukan"
+	case calendarSubjectKelas:
+		q, notFound = a.db.Table("kelaskuliah").Where("id_kls = ?", id), "Kelas tidak ditemukan"
+		if smart {
+			q = a.db.Table("kelas_kuliah").Where("id_kls = ?", id)
+		}
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return NewNotFoundError(notFound)
+	}
+
+	return nil
+}
+
+func calendarTokenError(ctx *fiber.Ctx, code int, err error) error {
+	return ctx.Status(code).JSON(fiber.Map{
+		"code":    code,
+		"status":  http.StatusText(code),
+		"success": false,
+		"message": err.Error(),
+	})
+}
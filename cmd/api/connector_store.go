@@ -0,0 +1,45 @@
+package main
+
+import "gorm.io/gorm/schema"
+
+const connectorMigrationsDir = "migrations"
+
+// connectorTables adalah tabel milik connector yang dibuat dari file SQL pada direktori migrations.
+var connectorTables = []schema.Tabler{
+	DeletionSnapshot{},
+	AuditLog{},
+	IdempotencyKey{},
+	CalendarToken{},
+}
+
+// connectorIndexes adalah unique index pada tabel SIAKAD yang dibuat dari direktori migrations
+// dan dibutuhkan agar upsert tidak membuat baris ganda.
+var connectorIndexes = []struct {
+	table schema.Tabler
+	name  string
+}{
+	{KelasKuliahBobotNilai{}, "uniq_kelaskuliah_bobot_nilai_id_kls"},
+	{PresensiMahasiswa{}, "uniq_presensi_mahasiswa_sesi_pd"},
+	{PresensiDosen{}, "uniq_presensi_dosen_sesi_ptk"},
+}
+
+// CheckConnectorTables memastikan tabel milik connector dan index yang dibutuhkan sudah dibuat. Tabel tidak dibuat otomatis karena
+// user aplikasi pada database SIAKAD umumnya tidak memiliki hak DDL, sehingga tabel yang belum ada
+// hanya dicatat di log dan endpoint yang memakainya akan gagal sampai migrations dijalankan.
+func (a *ApplicationServer) CheckConnectorTables() {
+	migrator := a.db.Migrator()
+
+	for _, table := range connectorTables {
+		if !migrator.HasTable(table.TableName()) {
+			a.logger.Warn("connector table does not exist, run the SQL files in "+connectorMigrationsDir,
+				"table", table.TableName())
+		}
+	}
+
+	for _, index := range connectorIndexes {
+		if migrator.HasTable(index.table.TableName()) && !migrator.HasIndex(index.table.TableName(), index.name) {
+			a.logger.Warn("connector index does not exist, run the SQL files in "+connectorMigrationsDir,
+				"table", index.table.TableName(), "index", index.name)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+type (
+	ListDeletionsRequest struct {
+		gl.Filter
+		gl.SyncFilter
+	}
+
+	DeletedRecord struct {
+		ID        string    `json:"id" gorm:"column:id"`
+		DeletedAt time.Time `json:"deleted_at" gorm:"column:deleted_at"`
+	}
+
+	// DeletionSnapshot menyimpan id yang pernah terlihat pada tabel sumber yang melakukan hard delete.
+	// Ketika id tidak lagi ditemukan, deleted_at diisi dengan waktu saat penghapusan terdeteksi.
+	DeletionSnapshot struct {
+		Resource  string     `gorm:"column:resource;primaryKey;type:varchar(64)"`
+		RecordID  string     `gorm:"column:record_id;primaryKey;type:varchar(191)"`
+		DeletedAt *time.Time `gorm:"column:deleted_at;index"`
+		CreatedAt *time.Time `gorm:"column:created_at"`
+		UpdatedAt *time.Time `gorm:"column:updated_at"`
+	}
+
+	// deletionSource menjelaskan cara mendapatkan id yang terhapus untuk satu resource.
+	// Jika softDeleteColumn diisi, data diambil langsung dari kolom tersebut,
+	// jika tidak, id dibandingkan dengan DeletionSnapshot.
+	deletionSource struct {
+		resource         string
+		table            string
+		idColumn         string
+		softDeleteColumn string
+		// snapshotJoin mencocokkan snapshot (alias s) dengan tabel sumber, default idColumn = s.record_id.
+		// Diisi jika idColumn berupa ekspresi agar join tetap bisa memakai index tabel sumber.
+		snapshotJoin string
+	}
+)
+
+func (DeletionSnapshot) TableName() string {
+	return "connector_deletion_snapshot"
+}
+
+var (
+	studentDeletionSourceMisca      = deletionSource{resource: "students", table: "mahasiswa", idColumn: "mahasiswa.id", softDeleteColumn: "mahasiswa.deleted_at"}
+	studentDeletionSourceSmart      = deletionSource{resource: "students", table: "mahasiswa", idColumn: "mahasiswa.id_pd"}
+	lecturerDeletionSourceMisca     = deletionSource{resource: "lecturers", table: "dosen", idColumn: "dosen.id_ptk"}
+	lecturerDeletionSourceSmart     = deletionSource{resource: "lecturers", table: "dosen", idColumn: "dosen.id_ptk"}
+	kelasDeletionSourceMisca        = deletionSource{resource: "classes", table: "kelaskuliah", idColumn: "kelaskuliah.id_kls"}
+	kelasDeletionSourceSmart        = deletionSource{resource: "classes", table: "kelas_kuliah", idColumn: "kelas_kuliah.id_kls"}
+	studentKelasDeletionSourceMisca = deletionSource{
+		resource:     "student_classes",
+		table:        "nilai",
+		idColumn:     "CONCAT(nilai.id_pd, ':', nilai.id_kls)",
+		snapshotJoin: "nilai.id_pd = SUBSTRING_INDEX(s.record_id, ':', 1) AND nilai.id_kls = SUBSTRING_INDEX(s.record_id, ':', -1)",
+	}
+	studentKelasDeletionSourceSmart = deletionSource{
+		resource:     "student_classes",
+		table:        "nilai",
+		idColumn:     "CONCAT(nilai.id_reg_pd, ':', nilai.id_kls)",
+		snapshotJoin: "nilai.id_reg_pd = SUBSTRING_INDEX(s.record_id, ':', 1) AND nilai.id_kls = SUBSTRING_INDEX(s.record_id, ':', -1)",
+	}
+	roomDeletionSourceMisca     = deletionSource{resource: "rooms", table: "ruangan", idColumn: "ruangan.id_ruangan"}
+	roomDeletionSourceSmart     = deletionSource{resource: "rooms", table: "ruangan", idColumn: "ruangan.id_ruangan"}
+	smsDeletionSourceMisca      = deletionSource{resource: "sms", table: "sms", idColumn: "sms.id_sms"}
+	smsDeletionSourceSmart      = deletionSource{resource: "sms", table: "sms", idColumn: "sms.id_sms"}
+	semesterDeletionSourceMisca = deletionSource{resource: "semesters", table: "semester", idColumn: "semester.id_smt"}
+	semesterDeletionSourceSmart = deletionSource{resource: "semesters", table: "semester", idColumn: "semester.id_smt"}
+
+	// snapshotDeletionSources adalah sumber hard delete yang di-refresh oleh RunDeletionSnapshotRefresher
+	snapshotDeletionSourcesMisca = []deletionSource{
+		lecturerDeletionSourceMisca,
+		kelasDeletionSourceMisca,
+		studentKelasDeletionSourceMisca,
+		roomDeletionSourceMisca,
+		smsDeletionSourceMisca,
+		semesterDeletionSourceMisca,
+	}
+	snapshotDeletionSourcesSmart = []deletionSource{
+		studentDeletionSourceSmart,
+		lecturerDeletionSourceSmart,
+		kelasDeletionSourceSmart,
+		studentKelasDeletionSourceSmart,
+		roomDeletionSourceSmart,
+		smsDeletionSourceSmart,
+		semesterDeletionSourceSmart,
+	}
+)
+
+func NewListDeletionsRequest() *ListDeletionsRequest {
+	return &ListDeletionsRequest{
+		Filter: gl.NewFilterPagination(),
+	}
+}
+
+// ListDeletions mengembalikan id dan waktu penghapusan sejak updated_since.
+// Id student_classes berformat "<id_pd>:<id_kls>".
+func (a *ApplicationServer) ListDeletions(misca, smart deletionSource) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		source := misca
+		if IsSmartInstansi(c) {
+			source = smart
+		}
+
+		req := NewListDeletionsRequest()
+		if err := c.QueryParser(req); err != nil {
+			return HandleError(c, err)
+		}
+
+		var q *gorm.DB
+		var deletedAtColumn string
+
+		if source.softDeleteColumn != "" {
+			q = a.db.
+				Table(source.table).
+				Select(source.idColumn + " AS id, " + source.softDeleteColumn + " AS deleted_at").
+				Where(source.softDeleteColumn + " IS NOT NULL")
+			deletedAtColumn = source.softDeleteColumn
+		} else {
+			q = a.db.
+				Model(&DeletionSnapshot{}).
+				Select("record_id AS id, deleted_at").
+				Where("resource = ? AND deleted_at IS NOT NULL", source.resource)
+			deletedAtColumn = "deleted_at"
+		}
+
+		q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, []string{deletedAtColumn + " >= ?"})
+		if err != nil {
+			return HandleError(c, err)
+		}
+
+		offset := req.Filter.GetOffset()
+		limit := req.Filter.GetLimit()
+
+		var totalData int64
+		if err := q.Count(&totalData).Error; err != nil {
+			return HandleError(c, err)
+		}
+
+		deleted := make([]DeletedRecord, 0)
+		if err := q.Order(deletedAtColumn + " ASC").Offset(int(offset)).Limit(int(limit)).Scan(&deleted).Error; err != nil {
+			return HandleError(c, err)
+		}
+
+		pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+		if err != nil {
+			return HandleError(c, err)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[DeletedRecord]]{
+			Code:    fiber.StatusOK,
+			Status:  http.StatusText(fiber.StatusOK),
+			Success: true,
+			Message: "Sukses mendapatkan data yang terhapus",
+			Data: ListDataApiResponseWrapper[DeletedRecord]{
+				List:     deleted,
+				PageInfo: pageInfo,
+				Sync:     syncInfo,
+			},
+		})
+	}
+}
+
+// RunDeletionSnapshotRefresher memperbarui snapshot semua sumber hard delete setiap DELETION_SNAPSHOT_INTERVAL,
+// sehingga /deletions hanya membaca snapshot. Penghapusan terdeteksi paling lambat satu interval setelah terjadi.
+// Skema instansi dikenali dari tabel kelas, kelaskuliah hanya ada pada Misca.
+func (a *ApplicationServer) RunDeletionSnapshotRefresher() {
+	if a.config.DeletionSnapshotInterval <= 0 {
+		return
+	}
+
+	sources := snapshotDeletionSourcesSmart
+	if a.db.Migrator().HasTable(kelasDeletionSourceMisca.table) {
+		sources = snapshotDeletionSourcesMisca
+	}
+
+	ticker := time.NewTicker(a.config.DeletionSnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, source := range sources {
+			if err := a.refreshDeletionSnapshot(source); err != nil {
+				a.logger.Error("failed to refresh deletion snapshot", "resource", source.resource, "error", err)
+			}
+		}
+
+		<-ticker.C
+	}
+}
+
+// refreshDeletionSnapshot membandingkan id pada tabel sumber dengan snapshot terakhir di dalam database.
+// Id baru dicatat, id yang hilang ditandai terhapus, dan id yang muncul kembali dipulihkan.
+// Penghapusan sebelum snapshot pertama dibuat tidak bisa terdeteksi.
+func (a *ApplicationServer) refreshDeletionSnapshot(source deletionSource) error {
+	snapshotTable := DeletionSnapshot{}.TableName()
+
+	snapshotJoin := source.snapshotJoin
+	if snapshotJoin == "" {
+		snapshotJoin = source.idColumn + " = s.record_id"
+	}
+
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		var now time.Time
+		if err := tx.Raw("SELECT NOW()").Scan(&now).Error; err != nil {
+			return err
+		}
+
+		// id baru
+		err := tx.Exec(
+			"INSERT INTO "+snapshotTable+" (resource, record_id, created_at, updated_at) "+
+				"SELECT DISTINCT ?, "+source.idColumn+", ?, ? FROM "+source.table+
+				" LEFT JOIN "+snapshotTable+" s ON s.resource = ? AND s.record_id = "+source.idColumn+
+				" WHERE "+source.idColumn+" IS NOT NULL AND s.record_id IS NULL",
+			source.resource, now, now, source.resource,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		// id yang muncul kembali
+		err = tx.Exec(
+			"UPDATE "+snapshotTable+" s JOIN "+source.table+" ON "+snapshotJoin+
+				" SET s.deleted_at = NULL, s.updated_at = ?"+
+				" WHERE s.resource = ? AND s.deleted_at IS NOT NULL",
+			now, source.resource,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		// id yang sudah tidak ada pada tabel sumber
+		return tx.Exec(
+			"UPDATE "+snapshotTable+" s LEFT JOIN "+source.table+" ON "+snapshotJoin+
+				" SET s.deleted_at = ?, s.updated_at = ?"+
+				" WHERE s.resource = ? AND s.deleted_at IS NULL AND "+source.idColumn+" IS NULL",
+			now, now, source.resource,
+		).Error
+	})
+}
@@ -25,3 +25,46 @@ func NewFiberErrorHandler() fiber.ErrorHandler {
 		})
 	}
 }
+
+// AppError adalah error yang sudah diketahui status HTTP-nya,
+// HandleError akan meresponnya sesuai HTTPStatusCode dan Info.
+type AppError struct {
+	code int
+	info string
+	err  error
+}
+
+func NewAppError(code int, info string, err error) *AppError {
+	return &AppError{code: code, info: info, err: err}
+}
+
+func NewBadRequestError(err error) *AppError {
+	return NewAppError(http.StatusBadRequest, err.Error(), err)
+}
+
+func NewNotFoundError(info string) *AppError {
+	return NewAppError(http.StatusNotFound, info, nil)
+}
+
+func NewNotImplementedError(err error) *AppError {
+	return NewAppError(http.StatusNotImplemented, err.Error(), err)
+}
+
+func (e *AppError) HTTPStatusCode() int {
+	return e.code
+}
+
+func (e *AppError) Info() string {
+	return e.info
+}
+
+func (e *AppError) Error() string {
+	if e.err == nil {
+		return e.info
+	}
+	return e.err.Error()
+}
+
+func (e *AppError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"strconv"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	// jumlah baris yang dikumpulkan dari hasil query sebelum transform dijalankan dan ditulis ke client
+	exportChunkSize = 500
+
+	ndjsonContentType     = "application/x-ndjson"
+	syncTokenHeaderKey    = "X-Sync-Token"
+	fullSnapshotHeaderKey = "X-Full-Snapshot"
+	exportErrorMessage    = "export terhenti karena terjadi kesalahan"
+)
+
+// ExportError ditulis sebagai baris terakhir jika terjadi error di tengah streaming,
+// karena status dan header sudah terlanjur dikirim ke client.
+type ExportError struct {
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+// exportIdentity dipakai ketika model hasil query sudah sama dengan response.
+func exportIdentity[T any](items []T) ([]T, error) {
+	return items, nil
+}
+
+// StreamNDJSON menjalankan query sekali dan membaca hasilnya baris demi baris lewat Rows, lalu menulis setiap
+// baris sebagai satu baris JSON per chunk exportChunkSize baris, sehingga memori yang dipakai tetap terbatas
+// berapapun jumlah datanya dan tidak ada baris yang terulang atau terlewat antar chunk. Urutannya mengikuti
+// ORDER BY query list. transform yang menjalankan query tambahan memakai koneksi lain dari pool selama hasil
+// query utama masih dibaca. Informasi sinkronisasi dikirim melalui header.
+func StreamNDJSON[M any, R any](c *fiber.Ctx, q *gorm.DB, syncInfo *gl.SyncInfo, transform func([]M) ([]R, error)) error {
+	c.Set(fiber.HeaderContentType, ndjsonContentType)
+	if syncInfo != nil {
+		if syncInfo.SyncToken != "" {
+			c.Set(syncTokenHeaderKey, syncInfo.SyncToken)
+		}
+		c.Set(fullSnapshotHeaderKey, strconv.FormatBool(syncInfo.FullSnapshot))
+	}
+
+	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		encoder := json.NewEncoder(w)
+
+		writeError := func(err error) {
+			_ = encoder.Encode(ExportError{Message: exportErrorMessage, Error: err.Error()})
+			_ = w.Flush()
+		}
+
+		tx := q.Session(&gorm.Session{})
+		rows, err := tx.Rows()
+		if err != nil {
+			writeError(err)
+			return
+		}
+		defer rows.Close()
+
+		writeChunk := func(chunk []M) error {
+			items, err := transform(chunk)
+			if err != nil {
+				return err
+			}
+
+			for _, item := range items {
+				if err := encoder.Encode(item); err != nil {
+					return err
+				}
+			}
+
+			return w.Flush()
+		}
+
+		chunk := make([]M, 0, exportChunkSize)
+		for rows.Next() {
+			var model M
+			if err := tx.ScanRows(rows, &model); err != nil {
+				writeError(err)
+				return
+			}
+			chunk = append(chunk, model)
+
+			if len(chunk) < exportChunkSize {
+				continue
+			}
+
+			if err := writeChunk(chunk); err != nil {
+				writeError(err)
+				return
+			}
+			chunk = make([]M, 0, exportChunkSize)
+		}
+
+		if err := rows.Err(); err != nil {
+			writeError(err)
+			return
+		}
+
+		if len(chunk) > 0 {
+			if err := writeChunk(chunk); err != nil {
+				writeError(err)
+			}
+		}
+	})
+
+	return nil
+}
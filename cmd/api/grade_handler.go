@@ -0,0 +1,425 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+type (
+	// KelasInfo adalah identitas minimal sebuah kelas kuliah.
+	KelasInfo struct {
+		IDKelas  string `json:"id_kelas" gorm:"column:id_kls"`
+		IDSMS    string `json:"id_sms" gorm:"column:id_sms"`
+		Semester string `json:"semester" gorm:"column:id_smt"`
+
+		// sks mata kuliah, dipakai untuk menentukan tipe kuliah
+		SKSTatapMuka float64 `json:"-" gorm:"column:sks_tm"`
+		SKSPraktikum float64 `json:"-" gorm:"column:sks_prak"`
+	}
+
+	ClassGradesRequest struct {
+		// tanggal acuan untuk memilih bobot_nilai yang berlaku (format YYYY-MM-DD, default hari ini)
+		Tanggal string `json:"tanggal" form:"tanggal" query:"tanggal"`
+	}
+
+	ClassGradesResponse struct {
+		KelasInfo
+		Bobot     *gl.GradeWeights `json:"bobot"`
+		Mahasiswa []StudentGrade   `json:"mahasiswa"`
+	}
+
+	StudentGrade struct {
+		IDPesertaDidik string                   `json:"id_pd"`
+		IDMahasiswa    string                   `json:"id_mahasiswa"`
+		NIK            string                   `json:"nik"`
+		Nama           string                   `json:"nama"`
+		Angkatan       string                   `json:"angkatan"`
+		Komponen       *gl.GradeComponentScores `json:"komponen"`
+		NilaiAkhir     *float64                 `json:"nilai_akhir"`
+		NilaiHuruf     *string                  `json:"nilai_huruf"`
+		NilaiIndeks    *float64                 `json:"nilai_indeks"`
+		Lengkap        bool                     `json:"lengkap"`
+		Keterangan     *string                  `json:"keterangan"`
+	}
+
+	studentGradeModelMisca struct {
+		IDPesertaDidik string   `gorm:"column:id_pd"`
+		IDMahasiswa    string   `gorm:"column:id_mahasiswa"`
+		NIK            string   `gorm:"column:nik"`
+		Nama           string   `gorm:"column:nama"`
+		Angkatan       string   `gorm:"column:angkatan"`
+		NilaiAbsensi   *float64 `gorm:"column:nilai_absensi"`
+		NilaiTugas     *float64 `gorm:"column:nilai_tugas"`
+		NilaiUTS       *float64 `gorm:"column:nilai_uts"`
+		NilaiUAS       *float64 `gorm:"column:nilai_uas"`
+	}
+
+	studentGradeModelSmart struct {
+		IDPesertaDidik string   `gorm:"column:id_pd"`
+		NIK            string   `gorm:"column:nik"`
+		Nama           string   `gorm:"column:nama"`
+		NilaiAngka     *float64 `gorm:"column:nilai_angka"`
+	}
+
+	// bobotNilaiSmart mengikuti kolom tabel bobot_nilai pada skema feeder (Smart)
+	bobotNilaiSmart struct {
+		NilaiHuruf      string     `gorm:"column:nilai_huruf"`
+		BobotNilaiMin   float64    `gorm:"column:bobot_nilai_min"`
+		BobotNilaiMaks  float64    `gorm:"column:bobot_nilai_maks"`
+		NilaiIndeks     float64    `gorm:"column:nilai_indeks"`
+		TglMulaiEfektif *time.Time `gorm:"column:tgl_mulai_efektif"`
+		TglAkhirEfektif *time.Time `gorm:"column:tgl_akhir_efektif"`
+	}
+)
+
+// defaultGradeWeights mengikuti nilai default kolom pada tabel kelaskuliah_bobot_nilai
+var defaultGradeWeights = gl.GradeWeights{Absensi: 10, Tugas: 20, UTS: 30, UAS: 40}
+
+var ErrInvalidTanggal = errors.New("tanggal harus menggunakan format YYYY-MM-DD")
+
+func (k KelasKuliahBobotNilai) Weights() gl.GradeWeights {
+	if !k.Exists() {
+		return defaultGradeWeights
+	}
+
+	return gl.GradeWeights{
+		Absensi: k.BobotAbsensi,
+		Tugas:   k.BobotTugas,
+		UTS:     k.BobotUTS,
+		UAS:     k.BobotUAS,
+	}
+}
+
+func (b BobotNilai) GradeRange() (gl.GradeRange, error) {
+	min, err := strconv.ParseFloat(b.Min, 64)
+	if err != nil {
+		return gl.GradeRange{}, errors.Wrapf(err, "bobot_nilai %d: min tidak valid", b.ID)
+	}
+
+	max, err := strconv.ParseFloat(b.Maks, 64)
+	if err != nil {
+		return gl.GradeRange{}, errors.Wrapf(err, "bobot_nilai %d: maks tidak valid", b.ID)
+	}
+
+	index, err := strconv.ParseFloat(b.NilaiIndeks, 64)
+	if err != nil {
+		return gl.GradeRange{}, errors.Wrapf(err, "bobot_nilai %d: nilai_indeks tidak valid", b.ID)
+	}
+
+	return gl.GradeRange{
+		Letter:         b.NilaiHuruf,
+		Min:            min,
+		Max:            max,
+		Index:          index,
+		Angkatan:       b.Angkatan,
+		EffectiveFrom:  b.TglMulaiEfektif,
+		EffectiveUntil: b.TglAkhirEfektif,
+	}, nil
+}
+
+func (b bobotNilaiSmart) GradeRange() gl.GradeRange {
+	return gl.GradeRange{
+		Letter:         b.NilaiHuruf,
+		Min:            b.BobotNilaiMin,
+		Max:            b.BobotNilaiMaks,
+		Index:          b.NilaiIndeks,
+		EffectiveFrom:  b.TglMulaiEfektif,
+		EffectiveUntil: b.TglAkhirEfektif,
+	}
+}
+
+func (r *ClassGradesRequest) GetTanggal() (time.Time, error) {
+	if r.Tanggal == "" {
+		return time.Now(), nil
+	}
+
+	tanggal, err := time.ParseInLocation(time.DateOnly, r.Tanggal, time.Local)
+	if err != nil {
+		return time.Time{}, NewBadRequestError(ErrInvalidTanggal)
+	}
+
+	return tanggal, nil
+}
+
+func (k KelasInfo) TipeKuliah() string {
+	return gl.ResolveTipeKuliah(k.SKSTatapMuka, k.SKSPraktikum)
+}
+
+func (a *ApplicationServer) FindKelasMisca(id string) (*KelasInfo, error) {
+	var kelas KelasInfo
+	err := a.db.
+		Table("kelaskuliah").
+		Select(`
+			kelaskuliah.id_kls AS id_kls,
+			kelaskuliah.id_sms AS id_sms,
+			kelaskuliah.id_smt AS id_smt,
+			COALESCE(matakuliah.sks_tm, 0) AS sks_tm,
+			COALESCE(matakuliah.sks_prak, 0) + COALESCE(matakuliah.sks_prak_lap, 0) AS sks_prak
+		`).
+		Joins("LEFT JOIN matakuliah_kurikulum ON matakuliah_kurikulum.id_mk_kur = kelaskuliah.id_mk_kur").
+		Joins("LEFT JOIN matakuliah ON matakuliah.id_mk = matakuliah_kurikulum.id_mk").
+		Where("kelaskuliah.id_kls = ?", id).
+		Take(&kelas).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, NewNotFoundError("Kelas tidak ditemukan")
+	}
+
+	return &kelas, err
+}
+
+func (a *ApplicationServer) FindKelasSmart(id string) (*KelasInfo, error) {
+	var kelas KelasInfo
+	err := a.db.
+		Table("kelas_kuliah").
+		Select(`
+			kelas_kuliah.id_kls AS id_kls,
+			kelas_kuliah.id_sms AS id_sms,
+			kelas_kuliah.id_smt AS id_smt,
+			COALESCE(matkul.sks_tm, 0) AS sks_tm,
+			COALESCE(matkul.sks_prak, 0) + COALESCE(matkul.sks_prak_lap, 0) AS sks_prak
+		`).
+		Joins("LEFT JOIN matkul ON matkul.id_mk = kelas_kuliah.id_mk").
+		Where("kelas_kuliah.id_kls = ?", id).
+		Take(&kelas).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, NewNotFoundError("Kelas tidak ditemukan")
+	}
+
+	return &kelas, err
+}
+
+// GetGradeRangesMisca mengambil seluruh bobot_nilai milik prodi, pemilihan angkatan
+// dan tanggal efektif dilakukan oleh gl.ResolveGradeRange. db bisa berupa transaksi yang sedang berjalan.
+func GetGradeRangesMisca(db *gorm.DB, idSMS string) ([]gl.GradeRange, error) {
+	bobot := make([]BobotNilai, 0)
+	if err := db.Where("id_sms = ?", idSMS).Find(&bobot).Error; err != nil {
+		return nil, err
+	}
+
+	ranges := make([]gl.GradeRange, 0, len(bobot))
+	for _, b := range bobot {
+		r, err := b.GradeRange()
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, nil
+}
+
+func GetGradeRangesSmart(db *gorm.DB, idSMS string) ([]gl.GradeRange, error) {
+	bobot := make([]bobotNilaiSmart, 0)
+	if err := db.Table("bobot_nilai").Where("id_sms = ?", idSMS).Scan(&bobot).Error; err != nil {
+		return nil, err
+	}
+
+	ranges := make([]gl.GradeRange, 0, len(bobot))
+	for _, b := range bobot {
+		ranges = append(ranges, b.GradeRange())
+	}
+
+	return ranges, nil
+}
+
+func GetKelasGradeWeightsMisca(db *gorm.DB, idKelas string) (gl.GradeWeights, error) {
+	var bobot KelasKuliahBobotNilai
+	err := db.Where("id_kls = ?", idKelas).Limit(1).Find(&bobot).Error
+	if err != nil {
+		return gl.GradeWeights{}, err
+	}
+
+	return bobot.Weights(), nil
+}
+
+func (a *ApplicationServer) ListClassGradesMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ListClassGradesSmart(c)
+	}
+
+	req := new(ClassGradesRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	tanggal, err := req.GetTanggal()
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	kelas, err := a.FindKelasMisca(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	weights, err := GetKelasGradeWeightsMisca(a.db, kelas.IDKelas)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	ranges, err := GetGradeRangesMisca(a.db, kelas.IDSMS)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	models := make([]studentGradeModelMisca, 0)
+	err = a.db.Table("nilai").
+		Select(`
+			nilai.id_pd AS id_pd,
+			mahasiswa.id AS id_mahasiswa,
+			mahasiswa.nik AS nik,
+			mahasiswa.nama_mahasiswa AS nama,
+			mahasiswa_histori.angkatan AS angkatan,
+			nilai.nilai_absensi AS nilai_absensi,
+			nilai.nilai_tugas AS nilai_tugas,
+			nilai.nilai_uts AS nilai_uts,
+			nilai.nilai_uas AS nilai_uas
+		`).
+		Joins("JOIN mahasiswa_histori ON mahasiswa_histori.id_pd = nilai.id_pd").
+		Joins("JOIN mahasiswa ON mahasiswa.id = mahasiswa_histori.id_mahasiswa").
+		Where("nilai.id_kls = ?", kelas.IDKelas).
+		Order("mahasiswa.nama_mahasiswa ASC").
+		Scan(&models).Error
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	grades := make([]StudentGrade, 0, len(models))
+	for _, m := range models {
+		scores := gl.GradeComponentScores{
+			Absensi: m.NilaiAbsensi,
+			Tugas:   m.NilaiTugas,
+			UTS:     m.NilaiUTS,
+			UAS:     m.NilaiUAS,
+		}
+
+		grade := StudentGrade{
+			IDPesertaDidik: m.IDPesertaDidik,
+			IDMahasiswa:    m.IDMahasiswa,
+			NIK:            m.NIK,
+			Nama:           m.Nama,
+			Angkatan:       m.Angkatan,
+			Komponen:       &scores,
+		}
+
+		result, err := gl.ComputeGrade(scores, weights, ranges, m.Angkatan, tanggal)
+		switch {
+		case errors.Is(err, gl.ErrGradeRangeNotFound):
+			// nilai akhir tetap dikembalikan walaupun konversi huruf tidak ditemukan
+			info := err.Error()
+			grade.NilaiAkhir = &result.FinalScore
+			grade.Lengkap = result.Complete
+			grade.Keterangan = &info
+		case err != nil:
+			return HandleError(c, err)
+		default:
+			grade.NilaiAkhir = &result.FinalScore
+			grade.NilaiHuruf = &result.Letter
+			grade.NilaiIndeks = &result.Index
+			grade.Lengkap = result.Complete
+		}
+
+		grades = append(grades, grade)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ClassGradesResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data nilai kelas",
+		Data: ClassGradesResponse{
+			KelasInfo: *kelas,
+			Bobot:     &weights,
+			Mahasiswa: grades,
+		},
+	})
+}
+
+// ListClassGradesSmart: skema feeder hanya menyimpan nilai akhir (nilai_angka), sehingga
+// komponen dan bobot bernilai null dan nilai huruf dikonversi langsung dari nilai_angka.
+func (a *ApplicationServer) ListClassGradesSmart(c *fiber.Ctx) error {
+	req := new(ClassGradesRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	tanggal, err := req.GetTanggal()
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	kelas, err := a.FindKelasSmart(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	ranges, err := GetGradeRangesSmart(a.db, kelas.IDSMS)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	models := make([]studentGradeModelSmart, 0)
+	err = a.db.Table("nilai").
+		Select(`
+			nilai.id_reg_pd AS id_pd,
+			mahasiswa.nik AS nik,
+			mahasiswa.nm_pd AS nama,
+			nilai.nilai_angka AS nilai_angka
+		`).
+		Joins("JOIN mahasiswa ON mahasiswa.id_pd = nilai.id_reg_pd").
+		Where("nilai.id_kls = ?", kelas.IDKelas).
+		Order("mahasiswa.nm_pd ASC").
+		Scan(&models).Error
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	grades := make([]StudentGrade, 0, len(models))
+	for _, m := range models {
+		grade := StudentGrade{
+			IDPesertaDidik: m.IDPesertaDidik,
+			IDMahasiswa:    m.IDPesertaDidik,
+			NIK:            m.NIK,
+			Nama:           m.Nama,
+		}
+
+		if m.NilaiAngka != nil {
+			score := gl.RoundScore(*m.NilaiAngka)
+			grade.NilaiAkhir = &score
+			grade.Lengkap = true
+
+			r, err := gl.ResolveGradeRange(ranges, "", tanggal, score)
+			switch {
+			case errors.Is(err, gl.ErrGradeRangeNotFound):
+				info := err.Error()
+				grade.Keterangan = &info
+			case err != nil:
+				return HandleError(c, err)
+			default:
+				grade.NilaiHuruf = &r.Letter
+				grade.NilaiIndeks = &r.Index
+			}
+		}
+
+		grades = append(grades, grade)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ClassGradesResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data nilai kelas",
+		Data: ClassGradesResponse{
+			KelasInfo: *kelas,
+			Mahasiswa: grades,
+		},
+	})
+}
@@ -0,0 +1,511 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	gradesAuditResource = "grades"
+
+	// param setting berisi daftar id_smt yang nilainya dikunci, dipisah koma
+	lockedGradingSemesterParam = "kunci_nilai"
+
+	gradeChangeUpdate    = "update"
+	gradeChangeUnchanged = "unchanged"
+)
+
+type (
+	WriteClassGradesRequest struct {
+		DryRun  bool                `json:"dry_run"`
+		Tanggal string              `json:"tanggal"` // acuan bobot_nilai yang berlaku, default hari ini
+		Nilai   []StudentGradeInput `json:"nilai" validate:"required,min=1,dive"`
+	}
+
+	// StudentGradeInput: komponen yang tidak dikirim (null) memakai nilai yang sudah tersimpan.
+	// Smart tidak memiliki bobot komponen per kelas sehingga nilai_angka dikirim langsung.
+	StudentGradeInput struct {
+		IDPesertaDidik string   `json:"id_pd" validate:"required"`
+		NilaiAbsensi   *float64 `json:"nilai_absensi" validate:"omitempty,min=0,max=100"`
+		NilaiTugas     *float64 `json:"nilai_tugas" validate:"omitempty,min=0,max=100"`
+		NilaiUTS       *float64 `json:"nilai_uts" validate:"omitempty,min=0,max=100"`
+		NilaiUAS       *float64 `json:"nilai_uas" validate:"omitempty,min=0,max=100"`
+		NilaiAngka     *float64 `json:"nilai_angka" validate:"omitempty,min=0,max=100"`
+	}
+
+	// GradeRecord adalah nilai satu mahasiswa pada tabel nilai.
+	GradeRecord struct {
+		NilaiAbsensi *float64 `json:"nilai_absensi" gorm:"column:nilai_absensi"`
+		NilaiTugas   *float64 `json:"nilai_tugas" gorm:"column:nilai_tugas"`
+		NilaiUTS     *float64 `json:"nilai_uts" gorm:"column:nilai_uts"`
+		NilaiUAS     *float64 `json:"nilai_uas" gorm:"column:nilai_uas"`
+		NilaiAngka   *float64 `json:"nilai_angka" gorm:"column:nilai_angka"`
+		NilaiHuruf   *string  `json:"nilai_huruf" gorm:"column:nilai_huruf"`
+		NilaiIndeks  *float64 `json:"nilai_indeks" gorm:"column:nilai_indeks"`
+	}
+
+	GradeChange struct {
+		IDPesertaDidik string       `json:"id_pd"`
+		Aksi           string       `json:"aksi"` // update atau unchanged
+		Sebelum        *GradeRecord `json:"sebelum"`
+		Sesudah        GradeRecord  `json:"sesudah"`
+		Lengkap        bool         `json:"lengkap"`
+	}
+
+	WriteClassGradesResponse struct {
+		KelasInfo
+		DryRun bool `json:"dry_run"`
+		// null pada Smart karena nilai_angka dikirim langsung
+		Bobot     *gl.GradeWeights `json:"bobot"`
+		Diubah    int              `json:"diubah"`
+		Tetap     int              `json:"tetap"`
+		Perubahan []GradeChange    `json:"perubahan"`
+	}
+
+	existingGradeModel struct {
+		IDPesertaDidik string `gorm:"column:id_pd"`
+		GradeRecord
+	}
+
+	gradeStudentModel struct {
+		IDPesertaDidik string `gorm:"column:id_pd"`
+		Angkatan       string `gorm:"column:angkatan"`
+	}
+
+	// gradeWriteTarget membedakan cara menulis nilai pada skema Misca dan Smart.
+	// Fungsi yang menerima db dipanggil dengan transaksi penulisan nilai.
+	gradeWriteTarget struct {
+		// kolom id mahasiswa pada tabel nilai
+		studentColumn string
+		// skema feeder (Smart) tidak menyimpan komponen nilai, nilai_angka dikirim langsung
+		hasComponents bool
+		// Misca mengisi nilai.updated_at, dipakai oleh sinkronisasi updated_since
+		hasTimestamps bool
+		settingTable  string
+		findKelas     func(id string) (*KelasInfo, error)
+		activeSMT     func(db *gorm.DB) (string, error)
+		// nil jika instansi tidak memiliki bobot komponen per kelas
+		weights     func(db *gorm.DB, kelas *KelasInfo) (gl.GradeWeights, error)
+		gradeRanges func(db *gorm.DB, idSMS string) ([]gl.GradeRange, error)
+		students    func(db *gorm.DB, ids []string) ([]gradeStudentModel, error)
+	}
+)
+
+var (
+	ErrDuplicateGradeInput   = errors.New("id_pd dikirim lebih dari sekali")
+	ErrGradingLocked         = errors.New("nilai pada semester ini sudah dikunci")
+	ErrGradeComponentsSmart  = errors.New("instansi Smart tidak memiliki bobot komponen nilai per kelas, kirim nilai_angka")
+	ErrNilaiAngkaWithWeights = errors.New("nilai_angka dihitung dari komponen nilai dan bobot kelas, kirim komponen nilai")
+)
+
+func (a *ApplicationServer) gradeWriteTargetMisca() gradeWriteTarget {
+	return gradeWriteTarget{
+		studentColumn: "id_pd",
+		hasComponents: true,
+		hasTimestamps: true,
+		settingTable:  "setting",
+		findKelas:     a.FindKelasMisca,
+		activeSMT:     activeSemesterIDMisca,
+		weights: func(db *gorm.DB, kelas *KelasInfo) (gl.GradeWeights, error) {
+			// dikunci agar bobot tidak diubah PUT grading-weights sebelum nilai selesai ditulis
+			return GetKelasGradeWeightsMisca(db.Clauses(clause.Locking{Strength: "UPDATE"}), kelas.IDKelas)
+		},
+		gradeRanges: GetGradeRangesMisca,
+		students: func(db *gorm.DB, ids []string) ([]gradeStudentModel, error) {
+			students := make([]gradeStudentModel, 0)
+			err := db.Table("mahasiswa_histori").Select("id_pd, angkatan").Where("id_pd IN ?", ids).Scan(&students).Error
+			return students, err
+		},
+	}
+}
+
+// gradeWriteTargetSmart: kelas Smart tidak memiliki bobot per kelas sehingga nilai_angka dikirim langsung.
+func (a *ApplicationServer) gradeWriteTargetSmart() gradeWriteTarget {
+	return gradeWriteTarget{
+		studentColumn: "id_reg_pd",
+		settingTable:  "setting_app",
+		findKelas:     a.FindKelasSmart,
+		activeSMT:     activeSemesterIDSmart,
+		gradeRanges:   GetGradeRangesSmart,
+		students: func(db *gorm.DB, ids []string) ([]gradeStudentModel, error) {
+			students := make([]gradeStudentModel, 0)
+			err := db.Table("mahasiswa").Select("id_pd, '' AS angkatan").Where("id_pd IN ?", ids).Scan(&students).Error
+			return students, err
+		},
+	}
+}
+
+func (r GradeRecord) Equal(other GradeRecord) bool {
+	return equalPtr(r.NilaiAbsensi, other.NilaiAbsensi) &&
+		equalPtr(r.NilaiTugas, other.NilaiTugas) &&
+		equalPtr(r.NilaiUTS, other.NilaiUTS) &&
+		equalPtr(r.NilaiUAS, other.NilaiUAS) &&
+		equalPtr(r.NilaiAngka, other.NilaiAngka) &&
+		equalPtr(r.NilaiHuruf, other.NilaiHuruf) &&
+		equalPtr(r.NilaiIndeks, other.NilaiIndeks)
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// columns mengembalikan kolom yang ditulis ke tabel nilai.
+func (r GradeRecord) columns(hasComponents bool) map[string]interface{} {
+	columns := map[string]interface{}{
+		"nilai_angka":  r.NilaiAngka,
+		"nilai_huruf":  r.NilaiHuruf,
+		"nilai_indeks": r.NilaiIndeks,
+	}
+
+	if hasComponents {
+		columns["nilai_absensi"] = r.NilaiAbsensi
+		columns["nilai_tugas"] = r.NilaiTugas
+		columns["nilai_uts"] = r.NilaiUTS
+		columns["nilai_uas"] = r.NilaiUAS
+	}
+
+	return columns
+}
+
+func (a *ApplicationServer) WriteClassGradesMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.WriteClassGradesSmart(c)
+	}
+
+	return a.writeClassGrades(c, a.gradeWriteTargetMisca())
+}
+
+func (a *ApplicationServer) WriteClassGradesSmart(c *fiber.Ctx) error {
+	return a.writeClassGrades(c, a.gradeWriteTargetSmart())
+}
+
+// writeClassGrades menghitung nilai akhir dan nilai huruf lalu menulis ke tabel nilai dalam satu transaksi.
+// Dengan dry_run (body atau ?dry_run=true) perubahan hanya dikembalikan tanpa ditulis.
+func (a *ApplicationServer) writeClassGrades(c *fiber.Ctx, target gradeWriteTarget) error {
+	req := new(WriteClassGradesRequest)
+	if err := c.BodyParser(req); err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	dryRun := req.DryRun || c.QueryBool("dry_run")
+
+	tanggal, err := (&ClassGradesRequest{Tanggal: req.Tanggal}).GetTanggal()
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	ids := make([]string, 0, len(req.Nilai))
+	seen := make(map[string]struct{}, len(req.Nilai))
+	for _, n := range req.Nilai {
+		if _, ok := seen[n.IDPesertaDidik]; ok {
+			return HandleError(c, NewBadRequestError(errors.Wrap(ErrDuplicateGradeInput, n.IDPesertaDidik)))
+		}
+		seen[n.IDPesertaDidik] = struct{}{}
+		ids = append(ids, n.IDPesertaDidik)
+
+		if !target.hasComponents && n.NilaiAngka == nil {
+			return HandleError(c, NewNotImplementedError(errors.Wrap(ErrGradeComponentsSmart, n.IDPesertaDidik)))
+		}
+		if target.hasComponents && n.NilaiAngka != nil {
+			return HandleError(c, NewBadRequestError(errors.Wrap(ErrNilaiAngkaWithWeights, n.IDPesertaDidik)))
+		}
+	}
+
+	kelas, err := target.findKelas(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// nilai, bobot, dan status kunci dibaca ulang di dalam transaksi (baris nilai dan bobot dengan FOR UPDATE)
+	// sehingga perubahan yang dikembalikan sama dengan yang ditulis meskipun ada request lain yang berjalan bersamaan
+	var weights *gl.GradeWeights
+	var changes []GradeChange
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkGradingLocked(tx, target, kelas.Semester); err != nil {
+			return err
+		}
+
+		if target.weights != nil {
+			w, err := target.weights(tx, kelas)
+			if err != nil {
+				return err
+			}
+			weights = &w
+		}
+
+		changes, err = diffGradeChanges(tx, target, kelas, req.Nilai, ids, weights, tanggal)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		return a.applyGradeChanges(c, tx, target, kelas, changes)
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	response := WriteClassGradesResponse{
+		KelasInfo: *kelas,
+		DryRun:    dryRun,
+		Bobot:     weights,
+		Perubahan: changes,
+	}
+
+	for _, change := range changes {
+		switch change.Aksi {
+		case gradeChangeUpdate:
+			response.Diubah++
+		default:
+			response.Tetap++
+		}
+	}
+
+	message := "Sukses menyimpan data nilai kelas"
+	if dryRun {
+		message = "Sukses menghitung perubahan nilai kelas (dry run)"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[WriteClassGradesResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: message,
+		Data:    response,
+	})
+}
+
+// diffGradeChanges menghitung nilai akhir dan nilai huruf setiap mahasiswa dan membandingkannya dengan nilai tersimpan.
+// Hanya mahasiswa yang sudah memiliki baris nilai pada kelas (peserta kelas) yang bisa diberi nilai.
+// weights nil berarti nilai_angka dikirim langsung. Semua mahasiswa yang tidak valid ditolak sekaligus dengan 422.
+func diffGradeChanges(tx *gorm.DB, target gradeWriteTarget, kelas *KelasInfo, inputs []StudentGradeInput, ids []string, weights *gl.GradeWeights, tanggal time.Time) ([]GradeChange, error) {
+	ranges, err := target.gradeRanges(tx, kelas.IDSMS)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := target.students(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	angkatan := make(map[string]string, len(students))
+	for _, s := range students {
+		angkatan[s.IDPesertaDidik] = s.Angkatan
+	}
+
+	existing, err := findExistingGrades(tx, target, kelas.IDKelas, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]GradeChange, 0, len(inputs))
+	invalid := make([]string, 0)
+
+	for _, n := range inputs {
+		before, enrolled := existing[n.IDPesertaDidik]
+		if !enrolled {
+			invalid = append(invalid, fmt.Sprintf("%s: %s", n.IDPesertaDidik, ErrStudentNotEnrolled.Error()))
+			continue
+		}
+
+		if _, ok := angkatan[n.IDPesertaDidik]; !ok {
+			invalid = append(invalid, fmt.Sprintf("%s: mahasiswa tidak ditemukan", n.IDPesertaDidik))
+			continue
+		}
+
+		var after GradeRecord
+		var result gl.GradeResult
+
+		if weights != nil {
+			after = GradeRecord{
+				NilaiAbsensi: coalescePtr(n.NilaiAbsensi, before.NilaiAbsensi),
+				NilaiTugas:   coalescePtr(n.NilaiTugas, before.NilaiTugas),
+				NilaiUTS:     coalescePtr(n.NilaiUTS, before.NilaiUTS),
+				NilaiUAS:     coalescePtr(n.NilaiUAS, before.NilaiUAS),
+			}
+
+			scores := gl.GradeComponentScores{
+				Absensi: after.NilaiAbsensi,
+				Tugas:   after.NilaiTugas,
+				UTS:     after.NilaiUTS,
+				UAS:     after.NilaiUAS,
+			}
+
+			result, err = gl.ComputeGrade(scores, *weights, ranges, angkatan[n.IDPesertaDidik], tanggal)
+			if !result.Complete && errors.Is(err, gl.ErrGradeRangeNotFound) {
+				// nilai huruf tidak ditulis untuk nilai yang belum lengkap, rentangnya tidak dibutuhkan
+				err = nil
+			}
+		} else {
+			result = gl.GradeResult{FinalScore: gl.RoundScore(*n.NilaiAngka), Complete: true}
+
+			var r gl.GradeRange
+			r, err = gl.ResolveGradeRange(ranges, angkatan[n.IDPesertaDidik], tanggal, result.FinalScore)
+			result.Letter, result.Index = r.Letter, r.Index
+		}
+		if errors.Is(err, gl.ErrGradeRangeNotFound) {
+			invalid = append(invalid, fmt.Sprintf("%s: %s (nilai akhir %.2f)", n.IDPesertaDidik, err.Error(), result.FinalScore))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// komponen yang kosong dihitung 0, sehingga nilai akhir dari komponen yang belum lengkap tidak ditulis ke
+		// nilai resmi; nilai_angka, nilai_huruf, dan nilai_indeks dikosongkan sampai semua komponen terisi
+		if result.Complete {
+			after.NilaiAngka = &result.FinalScore
+			after.NilaiHuruf = &result.Letter
+			after.NilaiIndeks = &result.Index
+		}
+
+		if !target.hasComponents {
+			after.NilaiAbsensi, after.NilaiTugas, after.NilaiUTS, after.NilaiUAS = nil, nil, nil, nil
+		}
+
+		change := GradeChange{
+			IDPesertaDidik: n.IDPesertaDidik,
+			Aksi:           gradeChangeUpdate,
+			Sebelum:        &before,
+			Sesudah:        after,
+			Lengkap:        result.Complete,
+		}
+		if before.Equal(after) {
+			change.Aksi = gradeChangeUnchanged
+		}
+
+		changes = append(changes, change)
+	}
+
+	if len(invalid) > 0 {
+		info := strings.Join(invalid, ", ")
+		return nil, NewAppError(http.StatusUnprocessableEntity, "Nilai tidak bisa disimpan: "+info, errors.New(info))
+	}
+
+	return changes, nil
+}
+
+// applyGradeChanges hanya mengubah baris nilai yang sudah ada, baris baru (pengambilan kelas) dibuat oleh KRS.
+func (a *ApplicationServer) applyGradeChanges(c *fiber.Ctx, tx *gorm.DB, target gradeWriteTarget, kelas *KelasInfo, changes []GradeChange) error {
+	now := time.Now()
+
+	for _, change := range changes {
+		if change.Aksi != gradeChangeUpdate {
+			continue
+		}
+
+		columns := change.Sesudah.columns(target.hasComponents)
+		if target.hasTimestamps {
+			columns["updated_at"] = now
+		}
+
+		err := tx.Table("nilai").
+			Where("id_kls = ? AND "+target.studentColumn+" = ?", kelas.IDKelas, change.IDPesertaDidik).
+			Updates(columns).
+			Error
+		if err != nil {
+			return err
+		}
+
+		recordID := change.IDPesertaDidik + ":" + kelas.IDKelas
+		if err := writeAuditLog(c, tx, gradesAuditResource, recordID, auditActionUpdate, change.Sebelum, change.Sesudah); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findExistingGrades mengunci baris nilai mahasiswa pada kelas (FOR UPDATE) sampai transaksi selesai.
+func findExistingGrades(tx *gorm.DB, target gradeWriteTarget, idKelas string, ids []string) (map[string]GradeRecord, error) {
+	selects := []string{
+		"nilai." + target.studentColumn + " AS id_pd",
+		"nilai.nilai_angka AS nilai_angka",
+		"nilai.nilai_huruf AS nilai_huruf",
+		"nilai.nilai_indeks AS nilai_indeks",
+	}
+
+	if target.hasComponents {
+		selects = append(selects,
+			"nilai.nilai_absensi AS nilai_absensi",
+			"nilai.nilai_tugas AS nilai_tugas",
+			"nilai.nilai_uts AS nilai_uts",
+			"nilai.nilai_uas AS nilai_uas",
+		)
+	}
+
+	models := make([]existingGradeModel, 0)
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Table("nilai").
+		Select(strings.Join(selects, ", ")).
+		Where("nilai.id_kls = ? AND nilai."+target.studentColumn+" IN ?", idKelas, ids).
+		Scan(&models).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]GradeRecord, len(models))
+	for _, m := range models {
+		existing[m.IDPesertaDidik] = m.GradeRecord
+	}
+
+	return existing, nil
+}
+
+// checkGradingLocked menolak perubahan nilai pada semester yang sudah lewat
+// atau yang tercantum pada setting kunci_nilai.
+func checkGradingLocked(db *gorm.DB, target gradeWriteTarget, idSMT string) error {
+	activeSMT, err := target.activeSMT(db)
+	if err != nil {
+		return err
+	}
+
+	var lockedValue string
+	err = db.Table(target.settingTable).Where("param = ?", lockedGradingSemesterParam).Select("value").Scan(&lockedValue).Error
+	if err != nil {
+		return err
+	}
+
+	locked := make([]string, 0)
+	for _, s := range strings.Split(lockedValue, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			locked = append(locked, s)
+		}
+	}
+
+	if gl.IsGradingLocked(idSMT, activeSMT, locked) {
+		return NewAppError(http.StatusConflict, fmt.Sprintf("Nilai semester %s sudah dikunci", idSMT), ErrGradingLocked)
+	}
+
+	return nil
+}
+
+func coalescePtr[T any](values ...*T) *T {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
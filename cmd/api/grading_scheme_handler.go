@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+type (
+	GradingSchemeRequest struct {
+		IDSMS         string `json:"id_sms" form:"id_sms" query:"id_sms"`
+		Semester      string `json:"semester" form:"semester" query:"semester"`                   // default semester aktif
+		TipeKuliah    string `json:"tipe_kuliah" form:"tipe_kuliah" query:"tipe_kuliah"`          // teori, praktikum, teori_praktikum
+		TipePenilaian string `json:"tipe_penilaian" form:"tipe_penilaian" query:"tipe_penilaian"` // detail atau angka
+	}
+
+	ListGradingSchemesRequest struct {
+		gl.Filter
+		IDSMS         string `json:"id_sms" form:"id_sms" query:"id_sms"`
+		Semester      string `json:"semester" form:"semester" query:"semester"`
+		TipeKuliah    string `json:"tipe_kuliah" form:"tipe_kuliah" query:"tipe_kuliah"`          // teori, praktikum, teori_praktikum
+		TipePenilaian string `json:"tipe_penilaian" form:"tipe_penilaian" query:"tipe_penilaian"` // detail atau angka
+	}
+
+	GradingSchemeResponse struct {
+		ID            int                   `json:"id"`
+		Nama          string                `json:"nama"`
+		Semester      string                `json:"semester"`
+		IDSMS         []string              `json:"id_sms"`
+		TipeKuliah    string                `json:"tipe_kuliah"`
+		TipePenilaian string                `json:"tipe_penilaian"`
+		TotalBobot    float64               `json:"total_bobot"`
+		Komponen      []gl.GradingComponent `json:"komponen"`
+	}
+
+	ClassGradingSchemeResponse struct {
+		KelasInfo
+		TipeKuliah string                `json:"tipe_kuliah"`
+		Skema      GradingSchemeResponse `json:"skema"`
+	}
+)
+
+var (
+	ErrIDSMSRequired        = errors.New("id_sms wajib diisi")
+	ErrInvalidTipeKuliah    = errors.New("tipe_kuliah harus teori, praktikum, atau teori_praktikum")
+	ErrInvalidTipePenilaian = errors.New("tipe_penilaian harus detail atau angka")
+)
+
+func NewGradingSchemeRequest() *GradingSchemeRequest {
+	return &GradingSchemeRequest{
+		TipeKuliah:    gl.TipeKuliahTeoriPraktikum,
+		TipePenilaian: gl.TipePenilaianDetail,
+	}
+}
+
+func (r *GradingSchemeRequest) Validate() error {
+	if !slices.Contains([]string{gl.TipeKuliahTeori, gl.TipeKuliahPraktikum, gl.TipeKuliahTeoriPraktikum}, r.TipeKuliah) {
+		return NewBadRequestError(ErrInvalidTipeKuliah)
+	}
+
+	if !slices.Contains([]string{gl.TipePenilaianDetail, gl.TipePenilaianAngka}, r.TipePenilaian) {
+		return NewBadRequestError(ErrInvalidTipePenilaian)
+	}
+
+	return nil
+}
+
+func NewListGradingSchemesRequest() *ListGradingSchemesRequest {
+	return &ListGradingSchemesRequest{
+		Filter: gl.NewFilterPagination(),
+	}
+}
+
+func (r *ListGradingSchemesRequest) Validate() error {
+	if r.TipeKuliah != "" && !slices.Contains([]string{gl.TipeKuliahTeori, gl.TipeKuliahPraktikum, gl.TipeKuliahTeoriPraktikum}, r.TipeKuliah) {
+		return NewBadRequestError(ErrInvalidTipeKuliah)
+	}
+
+	if r.TipePenilaian != "" && !slices.Contains([]string{gl.TipePenilaianDetail, gl.TipePenilaianAngka}, r.TipePenilaian) {
+		return NewBadRequestError(ErrInvalidTipePenilaian)
+	}
+
+	return nil
+}
+
+func newGradingSchemeResponse(unsur *UnsurNilai) (GradingSchemeResponse, error) {
+	components, err := gl.ParseGradingComponents(unsur.Unsur)
+	if err != nil {
+		return GradingSchemeResponse{}, errors.Wrapf(err, "unsur_nilai %d", unsur.ID)
+	}
+
+	// id_sms disimpan sebagai json array, jika gagal diparse kembalikan apa adanya
+	idSMS := make([]string, 0)
+	if err := json.Unmarshal([]byte(unsur.IDSMS), &idSMS); err != nil {
+		idSMS = []string{unsur.IDSMS}
+	}
+
+	return GradingSchemeResponse{
+		ID:            unsur.ID,
+		Nama:          unsur.Nama,
+		Semester:      unsur.IDSMT,
+		IDSMS:         idSMS,
+		TipeKuliah:    unsur.TipeKuliah,
+		TipePenilaian: unsur.TipePenilaian,
+		TotalBobot:    gl.TotalGradingWeight(components),
+		Komponen:      components,
+	}, nil
+}
+
+// findGradingScheme membungkus GetUnsurNilai agar data yang tidak ditemukan menjadi 404.
+func findGradingScheme(db *gorm.DB, idSMS, idSMT, tipeKuliah, tipePenilaian string) (GradingSchemeResponse, error) {
+	unsur, err := GetUnsurNilai(db, idSMS, idSMT, tipeKuliah, tipePenilaian)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return GradingSchemeResponse{}, NewNotFoundError("Unsur nilai tidak ditemukan")
+	}
+	if err != nil {
+		return GradingSchemeResponse{}, err
+	}
+
+	return newGradingSchemeResponse(unsur)
+}
+
+// ListGradingSchemesMisca mengembalikan semua unsur nilai, bisa difilter dengan id_sms, semester, tipe_kuliah,
+// dan tipe_penilaian. Berbeda dengan /grading-schemes/resolve, tipe_kuliah dicocokkan persis tanpa fallback.
+func (a *ApplicationServer) ListGradingSchemesMisca(c *fiber.Ctx) error {
+	req := NewListGradingSchemesRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return HandleError(c, err)
+	}
+
+	q := a.db.Model(&UnsurNilai{})
+	if req.IDSMS != "" {
+		q = q.Where("JSON_CONTAINS(id_sms, ?, '$')", fmt.Sprintf(`"%s"`, req.IDSMS))
+	}
+	if req.Semester != "" {
+		q = q.Where("id_smt = ?", req.Semester)
+	}
+	if req.TipeKuliah != "" {
+		q = q.Where("tipe_kuliah = ?", req.TipeKuliah)
+	}
+	if req.TipePenilaian != "" {
+		q = q.Where("tipe_penilaian = ?", req.TipePenilaian)
+	}
+	if req.Filter.HasKeyword() {
+		q = q.Where("nama LIKE ?", "%"+req.Filter.Keyword+"%")
+	}
+
+	if req.Filter.HasSort() {
+		q = q.Order(clause.OrderByColumn{
+			Column: clause.Column{Name: req.Filter.SortBy},
+			Desc:   req.Filter.IsDesc(),
+		})
+	} else {
+		q = q.Order("id_smt DESC, id ASC")
+	}
+
+	offset := req.Filter.GetOffset()
+	limit := req.Filter.GetLimit()
+
+	var totalData int64
+	if err := q.Count(&totalData).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	listUnsur := make([]UnsurNilai, 0)
+	if err := q.Offset(int(offset)).Limit(int(limit)).Find(&listUnsur).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	schemes := make([]GradingSchemeResponse, 0, len(listUnsur))
+	for i := range listUnsur {
+		scheme, err := newGradingSchemeResponse(&listUnsur[i])
+		if err != nil {
+			return HandleError(c, err)
+		}
+		schemes = append(schemes, scheme)
+	}
+
+	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[GradingSchemeResponse]]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data unsur nilai",
+		Data: ListDataApiResponseWrapper[GradingSchemeResponse]{
+			List:     schemes,
+			PageInfo: pageInfo,
+		},
+	})
+}
+
+// ResolveGradingSchemeMisca menentukan satu unsur nilai yang berlaku untuk prodi, semester, dan tipe kuliah.
+func (a *ApplicationServer) ResolveGradingSchemeMisca(c *fiber.Ctx) error {
+	req := NewGradingSchemeRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.IDSMS == "" {
+		return HandleError(c, NewBadRequestError(ErrIDSMSRequired))
+	}
+
+	if err := req.Validate(); err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Semester == "" {
+		getActiveSemesterID := a.getActiveSemesterIDMisca
+		if IsSmartInstansi(c) {
+			getActiveSemesterID = a.getActiveSemesterIDSmart
+		}
+
+		activeSemester, err := getActiveSemesterID()
+		if err != nil {
+			return HandleError(c, err)
+		}
+		req.Semester = activeSemester
+	}
+
+	scheme, err := findGradingScheme(a.db, req.IDSMS, req.Semester, req.TipeKuliah, req.TipePenilaian)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[GradingSchemeResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data unsur nilai",
+		Data:    scheme,
+	})
+}
+
+// resolveClassGradingSchemeMisca memakai unsur idUnsur jika ada, jika tidak dicari dari prodi,
+// semester, dan tipe kuliah kelas. db bisa berupa transaksi yang sedang berjalan.
+func resolveClassGradingSchemeMisca(db *gorm.DB, kelas *KelasInfo, idUnsur *int, tipePenilaian string) (GradingSchemeResponse, error) {
+	if idUnsur != nil {
+		var unsur UnsurNilai
+		if err := db.Where("id = ?", *idUnsur).Limit(1).Find(&unsur).Error; err != nil {
+			return GradingSchemeResponse{}, err
+		}
+
+		if unsur.Exists() {
+			return newGradingSchemeResponse(&unsur)
+		}
+	}
+
+	return findGradingScheme(db, kelas.IDSMS, kelas.Semester, kelas.TipeKuliah(), tipePenilaian)
+}
+
+// GetClassGradingSchemeMisca menentukan unsur nilai dari prodi, semester, dan tipe kuliah kelas.
+// Jika kelas sudah terikat ke unsur tertentu (kelaskuliah_bobot_nilai.id_unsur), unsur tersebut yang dipakai.
+func (a *ApplicationServer) GetClassGradingSchemeMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetClassGradingSchemeSmart(c)
+	}
+
+	tipePenilaian := c.Query("tipe_penilaian", gl.TipePenilaianDetail)
+	if !slices.Contains([]string{gl.TipePenilaianDetail, gl.TipePenilaianAngka}, tipePenilaian) {
+		return HandleError(c, NewBadRequestError(ErrInvalidTipePenilaian))
+	}
+
+	kelas, err := a.FindKelasMisca(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var bobot KelasKuliahBobotNilai
+	if err := a.db.Where("id_kls = ?", kelas.IDKelas).Limit(1).Find(&bobot).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	scheme, err := resolveClassGradingSchemeMisca(a.db, kelas, bobot.IDUnsur, tipePenilaian)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ClassGradingSchemeResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data unsur nilai kelas",
+		Data: ClassGradingSchemeResponse{
+			KelasInfo:  *kelas,
+			TipeKuliah: kelas.TipeKuliah(),
+			Skema:      scheme,
+		},
+	})
+}
+
+func (a *ApplicationServer) GetClassGradingSchemeSmart(c *fiber.Ctx) error {
+	tipePenilaian := c.Query("tipe_penilaian", gl.TipePenilaianDetail)
+	if !slices.Contains([]string{gl.TipePenilaianDetail, gl.TipePenilaianAngka}, tipePenilaian) {
+		return HandleError(c, NewBadRequestError(ErrInvalidTipePenilaian))
+	}
+
+	kelas, err := a.FindKelasSmart(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	scheme, err := findGradingScheme(a.db, kelas.IDSMS, kelas.Semester, kelas.TipeKuliah(), tipePenilaian)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ClassGradingSchemeResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data unsur nilai kelas",
+		Data: ClassGradingSchemeResponse{
+			KelasInfo:  *kelas,
+			TipeKuliah: kelas.TipeKuliah(),
+			Skema:      scheme,
+		},
+	})
+}
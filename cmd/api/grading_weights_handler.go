@@ -0,0 +1,292 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	gradingWeightsAuditResource = "grading_weights"
+
+	// default kolom jmlh_pertemuan pada tabel kelaskuliah_bobot_nilai
+	defaultJumlahPertemuan = 16
+)
+
+type (
+	UpdateGradingWeightsRequest struct {
+		BobotAbsensi  int             `json:"bobot_absensi" validate:"min=0,max=100"`
+		BobotTugas    int             `json:"bobot_tugas" validate:"min=0,max=100"`
+		BobotUTS      int             `json:"bobot_uts" validate:"min=0,max=100"`
+		BobotUAS      int             `json:"bobot_uas" validate:"min=0,max=100"`
+		BobotJSON     json.RawMessage `json:"bobot_json"`
+		JmlhPertemuan *int            `json:"jmlh_pertemuan" validate:"omitempty,min=1"`
+		IDUnsur       *int            `json:"id_unsur"`
+	}
+
+	GradingWeightsResponse struct {
+		KelasInfo
+		Tersimpan     bool                  `json:"tersimpan"` // false jika kelas masih memakai bobot default
+		Bobot         gl.GradeWeights       `json:"bobot"`
+		BobotJSON     []gl.GradingComponent `json:"bobot_json"`
+		JmlhPertemuan int                   `json:"jmlh_pertemuan"`
+		IDUnsur       *int                  `json:"id_unsur"`
+		UpdatedAt     *time.Time            `json:"updated_at"`
+	}
+)
+
+var (
+	ErrGradingWeightsSmart    = errors.New("bobot nilai per kelas hanya tersedia untuk instansi Misca")
+	ErrGradingSchemeRequired  = errors.New("unsur nilai kelas tidak ditemukan, bobot_json tidak bisa divalidasi")
+	ErrInvalidKelasID         = errors.New("id kelas tidak valid")
+	ErrInvalidGradingBodyJSON = errors.New("bobot_json harus berupa json")
+	ErrUnsurNilaiNotFound     = errors.New("id_unsur tidak ditemukan pada unsur_nilai")
+)
+
+// GetBobotJSON mengembalikan bobot_json sebagai string, bobot_json boleh dikirim
+// sebagai json langsung maupun string berisi json.
+func (r *UpdateGradingWeightsRequest) GetBobotJSON() (string, error) {
+	raw := strings.TrimSpace(string(r.BobotJSON))
+	if raw == "" || raw == "null" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(raw, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(raw), &s); err != nil {
+			return "", ErrInvalidGradingBodyJSON
+		}
+		raw = strings.TrimSpace(s)
+	}
+
+	if raw != "" && !json.Valid([]byte(raw)) {
+		return "", ErrInvalidGradingBodyJSON
+	}
+
+	return raw, nil
+}
+
+func (r *UpdateGradingWeightsRequest) Weights() gl.GradeWeights {
+	return gl.GradeWeights{
+		Absensi: r.BobotAbsensi,
+		Tugas:   r.BobotTugas,
+		UTS:     r.BobotUTS,
+		UAS:     r.BobotUAS,
+	}
+}
+
+func newGradingWeightsResponse(kelas *KelasInfo, bobot KelasKuliahBobotNilai) (GradingWeightsResponse, error) {
+	components, err := gl.ParseGradingComponents(bobot.BobotJSON)
+	if err != nil {
+		return GradingWeightsResponse{}, errors.Wrapf(err, "kelaskuliah_bobot_nilai %d", bobot.ID)
+	}
+
+	jmlhPertemuan := bobot.JmlhPertemuan
+	if !bobot.Exists() {
+		jmlhPertemuan = defaultJumlahPertemuan
+	}
+
+	return GradingWeightsResponse{
+		KelasInfo:     *kelas,
+		Tersimpan:     bobot.Exists(),
+		Bobot:         bobot.Weights(),
+		BobotJSON:     components,
+		JmlhPertemuan: jmlhPertemuan,
+		IDUnsur:       bobot.IDUnsur,
+		UpdatedAt:     bobot.UpdatedAt,
+	}, nil
+}
+
+func (a *ApplicationServer) GetClassGradingWeightsMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return HandleError(c, NewNotImplementedError(ErrGradingWeightsSmart))
+	}
+
+	kelas, err := a.FindKelasMisca(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var bobot KelasKuliahBobotNilai
+	if err := a.db.Where("id_kls = ?", kelas.IDKelas).Limit(1).Find(&bobot).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	response, err := newGradingWeightsResponse(kelas, bobot)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[GradingWeightsResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data bobot nilai kelas",
+		Data:    response,
+	})
+}
+
+// UpdateClassGradingWeightsMisca membuat atau mengubah baris kelaskuliah_bobot_nilai milik kelas.
+// bobot_json divalidasi terhadap unsur nilai kelas (id_unsur jika diisi, atau hasil GetUnsurNilai).
+func (a *ApplicationServer) UpdateClassGradingWeightsMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return HandleError(c, NewNotImplementedError(ErrGradingWeightsSmart))
+	}
+
+	req := new(UpdateGradingWeightsRequest)
+	if err := c.BodyParser(req); err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := req.Weights().Validate(); err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	bobotJSON, err := req.GetBobotJSON()
+	if err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	kelas, err := a.FindKelasMisca(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	idKelas, err := strconv.Atoi(kelas.IDKelas)
+	if err != nil {
+		return HandleError(c, NewBadRequestError(ErrInvalidKelasID))
+	}
+
+	var saved KelasKuliahBobotNilai
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		var bobot KelasKuliahBobotNilai
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_kls = ?", idKelas).Limit(1).Find(&bobot).Error
+		if err != nil {
+			return err
+		}
+
+		if req.IDUnsur != nil {
+			var unsur UnsurNilai
+			if err := tx.Where("id = ?", *req.IDUnsur).Limit(1).Find(&unsur).Error; err != nil {
+				return err
+			}
+			if !unsur.Exists() {
+				return NewBadRequestError(ErrUnsurNilaiNotFound)
+			}
+		}
+
+		idUnsur := req.IDUnsur
+		if idUnsur == nil {
+			idUnsur = bobot.IDUnsur
+		}
+
+		if bobotJSON != "" {
+			if err := validateBobotJSON(tx, kelas, idUnsur, bobotJSON); err != nil {
+				return err
+			}
+		}
+
+		var before interface{}
+		if bobot.Exists() {
+			// before menyimpan salinan nilai sebelum bobot diubah
+			before = bobot
+		}
+
+		jmlhPertemuan := bobot.JmlhPertemuan
+		if req.JmlhPertemuan != nil {
+			jmlhPertemuan = *req.JmlhPertemuan
+		} else if !bobot.Exists() {
+			jmlhPertemuan = defaultJumlahPertemuan
+		}
+
+		// upsert pada unique index id_kls (migrations/002), sehingga PUT bersamaan untuk kelas yang belum
+		// memiliki bobot tidak membuat dua baris. Map dipakai agar bobot 0 tidak diganti default kolom.
+		now := time.Now()
+		values := map[string]interface{}{
+			"id_kls":         idKelas,
+			"bobot_absensi":  req.BobotAbsensi,
+			"bobot_tugas":    req.BobotTugas,
+			"bobot_uts":      req.BobotUTS,
+			"bobot_uas":      req.BobotUAS,
+			"bobot_json":     bobotJSON,
+			"jmlh_pertemuan": jmlhPertemuan,
+			"id_unsur":       idUnsur,
+			"created_at":     now,
+			"updated_at":     now,
+		}
+
+		result := tx.Model(&KelasKuliahBobotNilai{}).
+			Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "id_kls"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"bobot_absensi", "bobot_tugas", "bobot_uts", "bobot_uas", "bobot_json", "jmlh_pertemuan", "id_unsur", "updated_at",
+				}),
+			}).
+			Create(values)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Where("id_kls = ?", idKelas).Take(&saved).Error; err != nil {
+			return err
+		}
+
+		// MySQL mengembalikan 1 baris terpengaruh untuk insert dan 2 untuk update pada ON DUPLICATE KEY UPDATE
+		action := auditActionUpdate
+		if result.RowsAffected == 1 {
+			action = auditActionCreate
+		}
+
+		return writeAuditLog(c, tx, gradingWeightsAuditResource, kelas.IDKelas, action, before, saved)
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	response, err := newGradingWeightsResponse(kelas, saved)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[GradingWeightsResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses menyimpan data bobot nilai kelas",
+		Data:    response,
+	})
+}
+
+func validateBobotJSON(tx *gorm.DB, kelas *KelasInfo, idUnsur *int, bobotJSON string) error {
+	components, err := gl.ParseGradingComponents(bobotJSON)
+	if err != nil {
+		return NewBadRequestError(err)
+	}
+
+	scheme, err := resolveClassGradingSchemeMisca(tx, kelas, idUnsur, gl.TipePenilaianDetail)
+	var appErr *AppError
+	if errors.As(err, &appErr) && appErr.HTTPStatusCode() == http.StatusNotFound {
+		return NewBadRequestError(ErrGradingSchemeRequired)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := gl.ValidateGradingComponents(components, scheme.Komponen); err != nil {
+		return NewBadRequestError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	simpleStudentKelasIDsQueryMisca = `
+		SELECT CAST(nilai.id_kls AS CHAR) FROM nilai
+		JOIN kelaskuliah ON kelaskuliah.id_kls = nilai.id_kls
+		WHERE nilai.id_pd = ? AND nilai.smt_ambil = ?
+		ORDER BY kelaskuliah.id_kls`
+
+	simpleStudentKelasIDsQuerySmart = `
+		SELECT CAST(nilai.id_kls AS CHAR) FROM nilai
+		JOIN kelas_kuliah ON kelas_kuliah.id_kls = nilai.id_kls
+		WHERE nilai.id_reg_pd = ? AND kelas_kuliah.id_smt = ?
+		ORDER BY kelas_kuliah.id_kls`
+)
+
+// groupConcatTruncated memeriksa hasil GROUP_CONCAT terhadap DB_GROUP_CONCAT_MAX_LEN dan mencatatnya di log,
+// karena MySQL memotong hasilnya tanpa error.
+func (a *ApplicationServer) groupConcatTruncated(resource, id, field, value string) bool {
+	if !gl.GroupConcatTruncated(value, a.config.DBGroupConcatMaxLen) {
+		return false
+	}
+
+	a.logger.Warn("group_concat result truncated",
+		"resource", resource,
+		"id", id,
+		"field", field,
+		"group_concat_max_len", a.config.DBGroupConcatMaxLen,
+	)
+	return true
+}
+
+// completeSimpleStudentKelas mengambil ulang id_kelas mahasiswa yang terpotong dengan query tanpa GROUP_CONCAT,
+// sehingga daftar kelas mahasiswa selalu lengkap. query berisi satu kolom id kelas dengan parameter id_pd dan semester.
+func (a *ApplicationServer) completeSimpleStudentKelas(list []ListSimpleStudentKelas, query string) error {
+	for i := range list {
+		if !a.groupConcatTruncated("student_classes", list[i].IDPd, "id_kelas", list[i].IDKelas) {
+			continue
+		}
+
+		kelasIDs := make([]string, 0)
+		if err := a.db.Raw(query, list[i].IDPd, list[i].Semester).Scan(&kelasIDs).Error; err != nil {
+			return err
+		}
+		list[i].IDKelas = strings.Join(kelasIDs, "|")
+	}
+
+	return nil
+}
+
+// completeTruncatedKelas mengambil ulang id_dosen_pengajar kelas yang terpotong dari tabel dosen pengajar
+// (ajarTable.ptkColumn). Kolom lama jadwal, nama_ruangan, dan total_pertemuan yang terpotong dicatat pada
+// field truncated; data lengkapnya tersedia pada schedule.
+func (a *ApplicationServer) completeTruncatedKelas(listKelas []ListKelasResponse, ajarTable, ptkColumn string) error {
+	for i := range listKelas {
+		kelas := &listKelas[i]
+
+		if a.groupConcatTruncated("classes", kelas.IDKelas, "id_dosen_pengajar", kelas.IDDosenPengajarStr) {
+			lecturers := make([]string, 0)
+			err := a.db.Table(ajarTable).
+				Distinct("CAST("+ptkColumn+" AS CHAR)").
+				Where("id_kls = ? AND "+ptkColumn+" IS NOT NULL", kelas.IDKelas).
+				Order(ptkColumn + " ASC").
+				Scan(&lecturers).Error
+			if err != nil {
+				return err
+			}
+			kelas.IDDosenPengajar = lecturers
+		}
+
+		for _, field := range []struct{ name, value string }{
+			{"jadwal", kelas.Jadwal},
+			{"nama_ruangan", kelas.NamaRuangan},
+			{"total_pertemuan", kelas.TotalPertemuan},
+		} {
+			if a.groupConcatTruncated("classes", kelas.IDKelas, field.name, field.value) {
+				kelas.Truncated = append(kelas.Truncated, field.name)
+			}
+		}
+	}
+
+	return nil
+}
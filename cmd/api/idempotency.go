@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	idempotencyKeyHeader       = "Idempotency-Key"
+	idempotentReplayedHeader   = "Idempotent-Replayed"
+	maxIdempotencyKeyLength    = 128
+	idempotencyKeyRetentionTTL = 7 * 24 * time.Hour
+	// idempotencyKeyPurgeInterval jarak antar penghapusan key yang sudah kedaluwarsa
+	idempotencyKeyPurgeInterval = time.Hour
+	// idempotencyKeyPendingTimeout batas waktu reservasi yang belum selesai (misalnya proses mati di tengah request)
+	// sebelum key boleh diambil alih oleh request berikutnya
+	idempotencyKeyPendingTimeout = 5 * time.Minute
+)
+
+// IdempotencyKey menyimpan response dari request tulis yang memakai header Idempotency-Key,
+// sehingga request yang dikirim ulang dengan key yang sama tidak menulis data dua kali.
+type IdempotencyKey struct {
+	// hash dari gabungan fingerprint api key, method, path, dan Idempotency-Key
+	Key         string `gorm:"column:key;primaryKey;type:varchar(64)"`
+	RequestHash string `gorm:"column:request_hash;type:varchar(64)"`
+	// 0 selama request pertama masih diproses (reservasi), diisi status response setelah selesai
+	StatusCode int        `gorm:"column:status_code"`
+	Response   []byte     `gorm:"column:response;type:longblob"`
+	CreatedAt  *time.Time `gorm:"column:created_at;index"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "connector_idempotency_key"
+}
+
+// RunIdempotencyKeyPurger menghapus key yang sudah kedaluwarsa secara berkala, dijalankan sebagai goroutine.
+func (a *ApplicationServer) RunIdempotencyKeyPurger() {
+	ticker := time.NewTicker(idempotencyKeyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		err := a.db.Where("created_at < ?", time.Now().Add(-idempotencyKeyRetentionTTL)).Delete(&IdempotencyKey{}).Error
+		if err != nil {
+			a.logger.Error("failed to purge expired idempotency keys", "error", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// WithIdempotencyKey dipasang setelah WithApiKey. Jika header Idempotency-Key dikirim, key direservasi
+// lebih dulu lewat primary key tabel, sehingga request bersamaan dengan key yang sama ditolak dengan 409
+// selama request pertama diproses. Response pertama yang tidak error 5xx disimpan dan dikembalikan lagi
+// untuk request berikutnya dengan key dan body yang sama. Key yang sama dengan body berbeda ditolak dengan 422.
+func (a *ApplicationServer) WithIdempotencyKey() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		idempotencyKey := ctx.Get(idempotencyKeyHeader)
+		if idempotencyKey == "" {
+			return ctx.Next()
+		}
+
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"code":    http.StatusBadRequest,
+				"status":  "Bad Request",
+				"success": false,
+				"message": "Idempotency-Key terlalu panjang",
+			})
+		}
+
+		fingerprint, _ := ctx.Locals(apiKeyFingerprintKey).(string)
+		key := hashString(fingerprint + " " + ctx.Method() + " " + ctx.Path() + " " + idempotencyKey)
+		requestHash := hashString(string(ctx.Body()))
+
+		reserved, err := a.reserveIdempotencyKey(key, requestHash)
+		if err != nil {
+			return HandleError(ctx, err)
+		}
+
+		if !reserved {
+			var stored IdempotencyKey
+			if err := a.db.Where("`key` = ?", key).Limit(1).Find(&stored).Error; err != nil {
+				return HandleError(ctx, err)
+			}
+
+			if stored.Key != "" && stored.RequestHash != requestHash {
+				return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+					"code":    http.StatusUnprocessableEntity,
+					"status":  "Unprocessable Entity",
+					"success": false,
+					"message": "Idempotency-Key sudah dipakai untuk request yang berbeda",
+				})
+			}
+
+			// reservasi dihapus (request pertama gagal 5xx) atau belum selesai
+			if stored.Key == "" || stored.StatusCode == 0 {
+				return ctx.Status(http.StatusConflict).JSON(fiber.Map{
+					"code":    http.StatusConflict,
+					"status":  "Conflict",
+					"success": false,
+					"message": "Request dengan Idempotency-Key yang sama sedang diproses, coba lagi nanti",
+				})
+			}
+
+			ctx.Set(idempotentReplayedHeader, "true")
+			ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return ctx.Status(stored.StatusCode).Send(stored.Response)
+		}
+
+		nextErr := ctx.Next()
+
+		statusCode := ctx.Response().StatusCode()
+		if nextErr != nil || statusCode >= http.StatusInternalServerError {
+			// reservasi dilepas agar request bisa di-retry dengan key yang sama
+			if err := a.db.Where("`key` = ? AND status_code = 0", key).Delete(&IdempotencyKey{}).Error; err != nil {
+				a.logger.Error("failed to release idempotency key", "error", err)
+			}
+			return nextErr
+		}
+
+		err = a.db.Model(&IdempotencyKey{}).Where("`key` = ?", key).Updates(map[string]any{
+			"status_code": statusCode,
+			"response":    append([]byte(nil), ctx.Response().Body()...),
+		}).Error
+		if err != nil {
+			// data sudah tersimpan, kegagalan menyimpan response cukup dicatat
+			a.logger.Error("failed to store idempotency key", "error", err)
+		}
+
+		return nil
+	}
+}
+
+// reserveIdempotencyKey menyisipkan baris reservasi (status_code 0) untuk key. Hasilnya false jika key
+// sudah ada, kecuali reservasi lama yang melewati idempotencyKeyPendingTimeout dengan body yang sama diambil alih.
+func (a *ApplicationServer) reserveIdempotencyKey(key, requestHash string) (bool, error) {
+	now := time.Now()
+	result := a.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&IdempotencyKey{
+		Key:         key,
+		RequestHash: requestHash,
+		StatusCode:  0,
+		CreatedAt:   &now,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	result = a.db.Model(&IdempotencyKey{}).
+		Where("`key` = ? AND request_hash = ? AND status_code = 0 AND created_at < ?", key, requestHash, now.Add(-idempotencyKeyPendingTimeout)).
+		Update("created_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,12 +2,13 @@ package main
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/pkg/errors"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	gl "lab.garudacyber.co.id/g-learning-connector"
 )
@@ -19,17 +20,33 @@ type (
 
 	ListStudentKelasRequest struct {
 		gl.Filter
+		gl.SyncFilter
 		Semester string `json:"semester" form:"semester" query:"semester"`
 	}
 
+	// KelasPerkuliahan adalah satu kelas pada list detail kelas mahasiswa. id_dosen_pengajar tetap string
+	// dipisah "|" untuk client lama, daftar dosennya sebagai array ada di dosen_pengajar.
 	KelasPerkuliahan struct {
-		IDKelas         string `json:"id_kelas"`
-		IDSMS           string `json:"id_sms"`
-		NamaKelas       string `json:"nama_kelas"`
-		NamaMatakuliah  string `json:"nama_matakuliah"`
-		KodeMatakuliah  string `json:"kode_matakuliah"`
-		IDDosenPengajar string `json:"id_dosen_pengajar"`
-		Jadwal          string `json:"jadwal"`
+		IDKelas         string          `json:"id_kelas" gorm:"column:id_kelas"`
+		IDSMS           string          `json:"id_sms" gorm:"column:id_sms"`
+		NamaKelas       string          `json:"nama_kelas" gorm:"column:nama_kelas"`
+		NamaMatakuliah  string          `json:"nama_matakuliah" gorm:"column:nama_matakuliah"`
+		KodeMatakuliah  string          `json:"kode_matakuliah" gorm:"column:kode_matakuliah"`
+		IDDosenPengajar string          `json:"id_dosen_pengajar" gorm:"-"`
+		DosenPengajar   []string        `json:"dosen_pengajar" gorm:"-"`
+		Jadwal          string          `json:"jadwal" gorm:"-"`
+		Schedule        []KelasSchedule `json:"schedule" gorm:"-"`
+	}
+
+	// KelasSchedule adalah satu baris jadwal mingguan kelas beserta ruangannya. Jadwal berbentuk string
+	// ("Senin-08:00:00-10:00:00|...") tetap dikirim untuk client lama.
+	KelasSchedule struct {
+		DayOfWeek int     `json:"day_of_week"`
+		DayName   string  `json:"day_name"`
+		Start     string  `json:"start"`
+		End       string  `json:"end"`
+		RoomID    *string `json:"room_id"`
+		RoomName  *string `json:"room_name"`
 	}
 
 	ListStudentKelasResponse struct {
@@ -40,18 +57,25 @@ type (
 		KelasPerkuliahan []KelasPerkuliahan `json:"kelas_perkuliahan"`
 	}
 
+	// ListStudentKelasModel adalah satu mahasiswa pada list detail kelas, kelasnya diambil terpisah per id_pd
 	ListStudentKelasModel struct {
-		IDPesertaDidik     string `json:"id_pd" gorm:"column:id_pd"`
-		IDMahasiswa        string `json:"id_mahasiswa" gorm:"column:id_mahasiswa"`
-		NIK                string `json:"nik" gorm:"column:nik"`
-		IDKelas            string `json:"id_kelas" gorm:"column:id_kelas"`
-		IDSMS              string `json:"id_sms" gorm:"column:id_sms"`
-		NamaKelas          string `json:"nama_kelas" gorm:"column:nama_kelas"`
-		NamaMataKuliah     string `json:"nama_matakuliah" gorm:"column:nama_matakuliah"`
-		KodeMataKuliah     string `json:"kode_matakuliah" gorm:"column:kode_matakuliah"`
-		IDPTKDosenPengajar string `json:"id_dosen_pengajar" gorm:"column:id_dosen_pengajar"`
-		Semester           string `json:"semester" gorm:"column:semester"`
-		Jadwal             string `json:"jadwal" gorm:"column:jadwal"`
+		IDPesertaDidik string `json:"id_pd" gorm:"column:id_pd"`
+		IDMahasiswa    string `json:"id_mahasiswa" gorm:"column:id_mahasiswa"`
+		NIK            string `json:"nik" gorm:"column:nik"`
+		Semester       string `json:"semester" gorm:"column:semester"`
+	}
+
+	// studentKelasRow adalah satu kelas yang diambil mahasiswa pada tabel nilai
+	studentKelasRow struct {
+		KelasPerkuliahan
+		IDPesertaDidik string `gorm:"column:id_pd"`
+		Semester       string `gorm:"column:semester"`
+	}
+
+	// kelasLecturerRow adalah satu dosen pengajar kelas pada akt_mengajar_dosen
+	kelasLecturerRow struct {
+		IDKelas string `gorm:"column:id_kls"`
+		IDPTK   string `gorm:"column:id_ptk"`
 	}
 
 	Ruangan struct {
@@ -115,68 +139,6 @@ type (
 	}
 )
 
-var (
-	ErrMismatchData = errors.New("Data mismatch: all pipe-separated fields must have the same number of elements")
-)
-
-func convertListKelasModels(models []ListStudentKelasModel) ([]ListStudentKelasResponse, error) {
-	responses := make([]ListStudentKelasResponse, 0)
-
-	for _, model := range models {
-		// Get the length of idKelasList as single source of truth
-		idKelasList := strings.Split(model.IDKelas, "|")
-		maxLen := len(idKelasList)
-
-		// Safely split other fields and ensure they have at least maxLen elements
-		safeGetElement := func(list []string, idx int) string {
-			if idx < len(list) {
-				return list[idx]
-			}
-			return "" // Return empty string for missing elements
-		}
-
-		// Split all fields
-		smsKelasList := strings.Split(model.IDSMS, "|")
-		namaKelasList := strings.Split(model.NamaKelas, "|")
-		namaMatakuliahList := strings.Split(model.NamaMataKuliah, "|")
-		kodeMatakuliahList := strings.Split(model.KodeMataKuliah, "|")
-		idDosenPengajarList := strings.Split(model.IDPTKDosenPengajar, "|")
-
-		// Handle jadwal field which might be completely empty
-		var jadwalList []string
-		if model.Jadwal != "" {
-			jadwalList = strings.Split(model.Jadwal, "|")
-		} else {
-			jadwalList = make([]string, maxLen) // Create empty list with correct length
-		}
-
-		// Build kelasPerkuliahan with safe access
-		kelasPerkuliahan := make([]KelasPerkuliahan, maxLen)
-		for i := 0; i < maxLen; i++ {
-			kelasPerkuliahan[i] = KelasPerkuliahan{
-				IDKelas:         idKelasList[i],
-				IDSMS:           safeGetElement(smsKelasList, i),
-				NamaKelas:       safeGetElement(namaKelasList, i),
-				NamaMatakuliah:  safeGetElement(namaMatakuliahList, i),
-				KodeMatakuliah:  safeGetElement(kodeMatakuliahList, i),
-				IDDosenPengajar: safeGetElement(idDosenPengajarList, i),
-				Jadwal:          safeGetElement(jadwalList, i),
-			}
-		}
-
-		// Add to response
-		responses = append(responses, ListStudentKelasResponse{
-			IDPesertaDidik:   model.IDPesertaDidik,
-			IDMahasiswa:      model.IDMahasiswa,
-			Nik:              model.NIK,
-			Semester:         model.Semester,
-			KelasPerkuliahan: kelasPerkuliahan,
-		})
-	}
-
-	return responses, nil
-}
-
 func NewListKelasRequest() *ListStudentKelasRequest {
 	return &ListStudentKelasRequest{
 		Filter: gl.NewFilterPagination(),
@@ -201,6 +163,63 @@ func (a *ApplicationServer) ListSimpleStudentKelasMisca(c *fiber.Ctx) error {
 		return HandleError(c, err)
 	}
 
+	// Model untuk menampung hasil query
+	listKelas := make([]ListSimpleStudentKelas, 0)
+
+	offset := req.Filter.GetOffset()
+	limit := req.Filter.GetLimit()
+
+	q, syncInfo, err := a.listSimpleStudentKelasQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// Menghitung jumlah total data
+	var totalData int64
+	if err := q.Count(&totalData).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	// Menambahkan limit dan offset
+	q = q.Offset(int(offset)).Limit(int(limit))
+
+	// Eksekusi query
+	if err := q.Scan(&listKelas).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	// id_kelas yang terpotong group_concat_max_len diambil ulang
+	if err := a.completeSimpleStudentKelas(listKelas, simpleStudentKelasIDsQueryMisca); err != nil {
+		return HandleError(c, err)
+	}
+
+	// Membuat informasi paginasi
+	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// Mengembalikan hasil sebagai CSV/XLSX jika diminta
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, simpleStudentKelasTable, listKelas, pageInfo)
+	}
+
+	// Mengembalikan hasil sebagai JSON
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListSimpleStudentKelas]]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data kelas sederhana",
+		Data: ListDataApiResponseWrapper[ListSimpleStudentKelas]{
+			List:     listKelas,
+			PageInfo: pageInfo,
+			Sync:     syncInfo,
+		},
+	})
+}
+
+// listSimpleStudentKelasQueryMisca membangun query kelas sederhana per mahasiswa (semester, filter, sync dan sorting) tanpa paginasi
+func (a *ApplicationServer) listSimpleStudentKelasQueryMisca(req *ListStudentKelasRequest) (*gorm.DB, *gl.SyncInfo, error) {
 	var activeSemester string
 
 	// Ambil semester aktif
@@ -210,7 +229,7 @@ func (a *ApplicationServer) ListSimpleStudentKelasMisca(c *fiber.Ctx) error {
 		Select("value").
 		Scan(&activeSemester).
 		Error; err != nil {
-		return HandleError(c, err)
+		return nil, nil, err
 	}
 
 	// Set default semester jika kosong
@@ -218,12 +237,6 @@ func (a *ApplicationServer) ListSimpleStudentKelasMisca(c *fiber.Ctx) error {
 		req.Semester = activeSemester
 	}
 
-	// Model untuk menampung hasil query
-	listKelas := make([]ListSimpleStudentKelas, 0)
-
-	offset := req.Filter.GetOffset()
-	limit := req.Filter.GetLimit()
-
 	// Query hanya mengambil kolom yang diperlukan
 	q := a.db.Table("nilai").
 		Select(`
@@ -244,6 +257,11 @@ func (a *ApplicationServer) ListSimpleStudentKelasMisca(c *fiber.Ctx) error {
 		q = q.Where("mahasiswa.nik LIKE ?", "%"+req.Filter.Keyword+"%")
 	}
 
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, simpleStudentKelasSyncConditionsMisca)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Sorting default atau sesuai request
 	if req.Filter.HasSort() {
 		q = q.Order(clause.OrderByColumn{
@@ -254,37 +272,7 @@ func (a *ApplicationServer) ListSimpleStudentKelasMisca(c *fiber.Ctx) error {
 		q = q.Order("nilai.id_pd ASC")
 	}
 
-	// Menghitung jumlah total data
-	var totalData int64
-	if err := q.Count(&totalData).Error; err != nil {
-		return HandleError(c, err)
-	}
-
-	// Menambahkan limit dan offset
-	q = q.Offset(int(offset)).Limit(int(limit))
-
-	// Eksekusi query
-	if err := q.Scan(&listKelas).Error; err != nil {
-		return HandleError(c, err)
-	}
-
-	// Membuat informasi paginasi
-	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
-	if err != nil {
-		return HandleError(c, err)
-	}
-
-	// Mengembalikan hasil sebagai JSON
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListSimpleStudentKelas]]{
-		Code:    fiber.StatusOK,
-		Status:  http.StatusText(fiber.StatusOK),
-		Success: true,
-		Message: "Sukses mendapatkan data kelas sederhana",
-		Data: ListDataApiResponseWrapper[ListSimpleStudentKelas]{
-			List:     listKelas,
-			PageInfo: pageInfo,
-		},
-	})
+	return q, syncInfo, nil
 }
 
 func (a *ApplicationServer) TotalListSimpleStudentKelasMisca(c *fiber.Ctx) error {
@@ -337,6 +325,63 @@ func (a *ApplicationServer) ListStudentKelasDetailsMisca(c *fiber.Ctx) error {
 		return HandleError(c, err)
 	}
 
+	// Model untuk menampung hasil query
+	listKelas := make([]ListStudentKelasModel, 0)
+
+	offset := req.Filter.GetOffset()
+	limit := req.Filter.GetLimit()
+
+	q, syncInfo, err := a.listStudentKelasDetailsQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// Menghitung jumlah total data
+	var totalData int64
+	if err := q.Count(&totalData).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	// Menambahkan limit dan offset
+	q = q.Offset(int(offset)).Limit(int(limit))
+
+	// Eksekusi query
+	if err := q.Scan(&listKelas).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	// Membuat informasi paginasi
+	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	listKelasResponse, err := a.buildStudentKelasDetailsMisca(listKelas, RequestLang(c))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// Mengembalikan hasil sebagai CSV/XLSX jika diminta
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, studentKelasDetailsTable, listKelasResponse, pageInfo)
+	}
+
+	// Mengembalikan hasil sebagai JSON
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListStudentKelasResponse]]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data kelas",
+		Data: ListDataApiResponseWrapper[ListStudentKelasResponse]{
+			List:     listKelasResponse,
+			PageInfo: pageInfo,
+			Sync:     syncInfo,
+		},
+	})
+}
+
+// listStudentKelasDetailsQueryMisca membangun query detail kelas per mahasiswa (semester, filter, sync dan sorting) tanpa paginasi
+func (a *ApplicationServer) listStudentKelasDetailsQueryMisca(req *ListStudentKelasRequest) (*gorm.DB, *gl.SyncInfo, error) {
 	var activeSemester string
 
 	if err := a.db.
@@ -345,7 +390,7 @@ func (a *ApplicationServer) ListStudentKelasDetailsMisca(c *fiber.Ctx) error {
 		Select("value").
 		Scan(&activeSemester).
 		Error; err != nil {
-		return HandleError(c, err)
+		return nil, nil, err
 	}
 
 	// set default semester
@@ -353,41 +398,13 @@ func (a *ApplicationServer) ListStudentKelasDetailsMisca(c *fiber.Ctx) error {
 		req.Semester = activeSemester
 	}
 
-	// Model untuk menampung hasil query
-	listKelas := make([]ListStudentKelasModel, 0)
-
-	offset := req.Filter.GetOffset()
-	limit := req.Filter.GetLimit()
-
-	// Membuat query
+	// Query hanya mengambil satu baris per mahasiswa, kelas, dosen dan jadwal diambil terpisah
+	// oleh buildStudentKelasDetailsMisca supaya tidak saling menggandakan baris
 	q := a.db.Table("nilai").
 		Select(`
 			nilai.id_pd AS id_pd,
 			mahasiswa.id AS id_mahasiswa,
 			mahasiswa.nik AS nik,
-			GROUP_CONCAT(kelaskuliah.id_kls ORDER BY kelaskuliah.id_kls SEPARATOR '|') AS id_kelas,
-			GROUP_CONCAT(kelaskuliah.id_sms ORDER BY kelaskuliah.id_sms SEPARATOR '|') AS id_sms,
-			GROUP_CONCAT(kelaskuliah.nm_kls ORDER BY kelaskuliah.id_kls SEPARATOR '|') AS nama_kelas,
-			GROUP_CONCAT(matakuliah.nm_mk ORDER BY kelaskuliah.id_kls SEPARATOR '|') AS nama_matakuliah,
-			GROUP_CONCAT(matakuliah.kode_mk ORDER BY kelaskuliah.id_kls SEPARATOR '|') AS kode_matakuliah,
-			GROUP_CONCAT(akt_mengajar_dosen.id_ptk ORDER BY akt_mengajar_dosen.id_ptk SEPARATOR '|') AS id_dosen_pengajar,
-			GROUP_CONCAT(
-				CONCAT(
-					CASE jadwal.hari
-						WHEN '0' THEN 'Minggu'
-						WHEN '1' THEN 'Senin'
-						WHEN '2' THEN 'Selasa'
-						WHEN '3' THEN 'Rabu'
-						WHEN '4' THEN 'Kamis'
-						WHEN '5' THEN 'Jumat'
-						WHEN '6' THEN 'Sabtu'
-						ELSE 'Unknown'
-					END, '-',
-					jadwal.jam_mulai, '-',
-					jadwal.jam_selesai
-				)
-				ORDER BY jadwal.id_jadwal SEPARATOR '|'
-			) AS jadwal,
 			nilai.smt_ambil AS semester
 		`).
 		Joins("JOIN mahasiswa_histori ON mahasiswa_histori.id_pd = nilai.id_pd").
@@ -395,8 +412,6 @@ func (a *ApplicationServer) ListStudentKelasDetailsMisca(c *fiber.Ctx) error {
 		Joins("JOIN kelaskuliah ON kelaskuliah.id_kls = nilai.id_kls").
 		Joins("JOIN matakuliah_kurikulum ON matakuliah_kurikulum.id_mk_kur = kelaskuliah.id_mk_kur").
 		Joins("JOIN matakuliah ON matakuliah.id_mk = matakuliah_kurikulum.id_mk").
-		Joins("LEFT JOIN akt_mengajar_dosen ON akt_mengajar_dosen.id_kls = kelaskuliah.id_kls").
-		Joins("LEFT JOIN jadwal ON jadwal.id_kls = kelaskuliah.id_kls").
 		Where("nilai.smt_ambil = ?", req.Semester).
 		Group("nilai.id_pd, mahasiswa.nik, nilai.smt_ambil")
 
@@ -405,6 +420,11 @@ func (a *ApplicationServer) ListStudentKelasDetailsMisca(c *fiber.Ctx) error {
 		q = q.Where("mahasiswa.nik LIKE ?", "%"+req.Filter.Keyword+"%")
 	}
 
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, studentKelasDetailsSyncConditionsMisca)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Menambahkan sorting
 	if req.Filter.HasSort() {
 		q = q.Order(clause.OrderByColumn{
@@ -415,42 +435,7 @@ func (a *ApplicationServer) ListStudentKelasDetailsMisca(c *fiber.Ctx) error {
 		q = q.Order("nilai.id_pd ASC")
 	}
 
-	// Menghitung jumlah total data
-	var totalData int64
-	if err := q.Count(&totalData).Error; err != nil {
-		return HandleError(c, err)
-	}
-
-	// Menambahkan limit dan offset
-	q = q.Offset(int(offset)).Limit(int(limit))
-
-	// Eksekusi query
-	if err := q.Scan(&listKelas).Error; err != nil {
-		return HandleError(c, err)
-	}
-
-	// Membuat informasi paginasi
-	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
-	if err != nil {
-		return HandleError(c, err)
-	}
-
-	listKelasResponse, err := convertListKelasModels(listKelas)
-	if err != nil {
-		return HandleError(c, err)
-	}
-
-	// Mengembalikan hasil sebagai JSON
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListStudentKelasResponse]]{
-		Code:    fiber.StatusOK,
-		Status:  http.StatusText(fiber.StatusOK),
-		Success: true,
-		Message: "Sukses mendapatkan data kelas",
-		Data: ListDataApiResponseWrapper[ListStudentKelasResponse]{
-			List:     listKelasResponse,
-			PageInfo: pageInfo,
-		},
-	})
+	return q, syncInfo, nil
 }
 
 func (a *ApplicationServer) GetTotalKelasDetailsMisca(c *fiber.Ctx) error {
@@ -508,9 +493,11 @@ type ListKelasResponse struct {
 	IDDosenPengajarStr string              `gorm:"column:id_dosen_pengajar"`
 	Semester           string              `json:"semester" gorm:"column:semester"`
 	Jadwal             string              `json:"jadwal" gorm:"column:jadwal"`
+	Schedule           []KelasSchedule     `json:"schedule" gorm:"-"`
 	NamaRuangan        string              `json:"nama_ruangan" gorm:"column:nama_ruangan"`
 	TotalPertemuan     string              `json:"total_pertemuan" gorm:"column:total_pertemuan"`
 	JadwalPerkuliahan  []JadwalPerkuliahan `json:"jadwal_perkuliahan" gorm:"-"`
+	Truncated          []string            `json:"truncated,omitempty" gorm:"-"` // kolom GROUP_CONCAT yang terpotong group_concat_max_len
 }
 
 type JadwalPerkuliahan struct {
@@ -579,39 +566,144 @@ func (a *ApplicationServer) ListKelasMisca(c *fiber.Ctx) error {
 	if err := c.QueryParser(req); err != nil {
 		return HandleError(c, err)
 	}
-	var activeSemester string
-	if err := a.db.
-		Table("setting").
-		Where("param = ?", "periode_berlaku").
-		Select("value").
-		Scan(&activeSemester).
-		Error; err != nil {
-		return HandleError(c, err)
-	}
-	// Set default semester
-	if req.Semester == "" {
-		req.Semester = activeSemester
-	}
 	// Model to hold query results
 	listKelas := make([]ListKelasResponse, 0)
 	offset := req.Filter.GetOffset()
 	limit := req.Filter.GetLimit()
-	q := a.db.
-		Table("kelaskuliah").
-		Select(`
-			kelaskuliah.id_kls AS id_kelas,
-			kelaskuliah.id_sms AS id_sms,
-			kelaskuliah.nm_kls AS nama_kelas,
-			matakuliah.nm_mk AS nama_matakuliah,
-			matakuliah.kode_mk AS kode_matakuliah,
-			GROUP_CONCAT(
-				DISTINCT CAST(akt_mengajar_dosen.id_ptk AS CHAR)
-				ORDER BY akt_mengajar_dosen.id_ptk ASC
-				SEPARATOR '|'
-			) AS id_dosen_pengajar,
-			kelaskuliah.id_smt AS semester,
-			GROUP_CONCAT(
-				CONCAT(
+	q, syncInfo, err := a.listKelasQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+	// Count total data
+	var totalData int64
+	if err := q.Count(&totalData).Error; err != nil {
+		return HandleError(c, err)
+	}
+	// Add limit and offset
+	q = q.Offset(int(offset)).Limit(int(limit))
+	// Execute query
+	if err := q.Scan(&listKelas).Error; err != nil {
+		return HandleError(c, err)
+	}
+	// Post-process id_dosen_pengajar, jadwal_perkuliahan and schedule
+	splitDosenPengajar(listKelas)
+	if err := a.completeTruncatedKelas(listKelas, "akt_mengajar_dosen", "id_ptk"); err != nil {
+		return HandleError(c, err)
+	}
+	a.attachJadwalPerkuliahan(listKelas)
+	if err := a.attachSchedules(listKelas, "ruangan.nama_ruangan", RequestLang(c)); err != nil {
+		return HandleError(c, err)
+	}
+
+	// Create pagination info
+	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+	if err != nil {
+		return HandleError(c, err)
+	}
+	// Return result as CSV/XLSX when requested
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, kelasTable, listKelas, pageInfo)
+	}
+
+	// Return result as JSON
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListKelasResponse]]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data kelas",
+		Data: ListDataApiResponseWrapper[ListKelasResponse]{
+			List:     listKelas,
+			PageInfo: pageInfo,
+			Sync:     syncInfo,
+		},
+	})
+}
+
+// listKelasQueryMisca membangun query list kelas (semester, filter, sync dan sorting) tanpa paginasi
+func (a *ApplicationServer) listKelasQueryMisca(req *ListStudentKelasRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	var activeSemester string
+	if err := a.db.
+		Table("setting").
+		Where("param = ?", "periode_berlaku").
+		Select("value").
+		Scan(&activeSemester).
+		Error; err != nil {
+		return nil, nil, err
+	}
+	// Set default semester
+	if req.Semester == "" {
+		req.Semester = activeSemester
+	}
+	q := a.kelasQueryMisca().Where("kelaskuliah.id_smt = ?", req.Semester)
+	// Add keyword search
+	if req.Filter.HasKeyword() {
+		q = q.Where("kelaskuliah.nm_kls LIKE ?", "%"+req.Filter.Keyword+"%")
+	}
+	// Add updated_since filter
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, kelasSyncConditionsMisca)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Add sorting
+	if req.Filter.HasSort() {
+		q = q.Order(clause.OrderByColumn{
+			Column: clause.Column{Name: req.Filter.SortBy},
+			Desc:   req.Filter.IsDesc(),
+		})
+	} else {
+		q = q.Order("kelaskuliah.id_kls ASC")
+	}
+
+	return q, syncInfo, nil
+}
+
+func (a *ApplicationServer) GetKelasMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetKelasSmart(c)
+	}
+
+	kelas, err := findOne[ListKelasResponse](a.kelasQueryMisca().Where("kelaskuliah.id_kls = ?", c.Params("id")), "Kelas tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	listKelas := []ListKelasResponse{kelas}
+	splitDosenPengajar(listKelas)
+	if err := a.completeTruncatedKelas(listKelas, "akt_mengajar_dosen", "id_ptk"); err != nil {
+		return HandleError(c, err)
+	}
+	a.attachJadwalPerkuliahan(listKelas)
+	if err := a.attachSchedules(listKelas, "ruangan.nama_ruangan", RequestLang(c)); err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListKelasResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data kelas",
+		Data:    listKelas[0],
+	})
+}
+
+// kelasQueryMisca adalah query dasar kelas (tanpa filter semester) yang dipakai oleh list dan detail
+func (a *ApplicationServer) kelasQueryMisca() *gorm.DB {
+	return a.db.
+		Table("kelaskuliah").
+		Select(`
+			kelaskuliah.id_kls AS id_kelas,
+			kelaskuliah.id_sms AS id_sms,
+			kelaskuliah.nm_kls AS nama_kelas,
+			matakuliah.nm_mk AS nama_matakuliah,
+			matakuliah.kode_mk AS kode_matakuliah,
+			GROUP_CONCAT(
+				DISTINCT CAST(akt_mengajar_dosen.id_ptk AS CHAR)
+				ORDER BY akt_mengajar_dosen.id_ptk ASC
+				SEPARATOR '|'
+			) AS id_dosen_pengajar,
+			kelaskuliah.id_smt AS semester,
+			GROUP_CONCAT(
+				CONCAT(
 					CASE jadwal.hari
 						WHEN '0' THEN 'Minggu'
 						WHEN '1' THEN 'Senin'
@@ -644,41 +736,24 @@ func (a *ApplicationServer) ListKelasMisca(c *fiber.Ctx) error {
 		Joins("LEFT JOIN akt_mengajar_dosen ON akt_mengajar_dosen.id_kls = kelaskuliah.id_kls").
 		Joins("LEFT JOIN jadwal ON jadwal.id_kls = kelaskuliah.id_kls").
 		Joins("LEFT JOIN ruangan ON ruangan.id_ruangan = jadwal.id_ruangan").
-		Where("kelaskuliah.id_smt = ?", req.Semester).
 		Group("kelaskuliah.id_kls")
-	// Add keyword search
-	if req.Filter.HasKeyword() {
-		q = q.Where("kelaskuliah.nm_kls LIKE ?", "%"+req.Filter.Keyword+"%")
-	}
-	// Add sorting
-	if req.Filter.HasSort() {
-		q = q.Order(clause.OrderByColumn{
-			Column: clause.Column{Name: req.Filter.SortBy},
-			Desc:   req.Filter.IsDesc(),
-		})
-	} else {
-		q = q.Order("kelaskuliah.id_kls ASC")
-	}
-	// Count total data
-	var totalData int64
-	if err := q.Count(&totalData).Error; err != nil {
-		return HandleError(c, err)
-	}
-	// Add limit and offset
-	q = q.Offset(int(offset)).Limit(int(limit))
-	// Execute query
-	if err := q.Scan(&listKelas).Error; err != nil {
-		return HandleError(c, err)
-	}
-	// Post-process id_dosen_pengajar to convert pipe-separated string to slice
-	// and initialize empty slice for jadwal_perkuliahan
+}
+
+// splitDosenPengajar converts the pipe-separated id_dosen_pengajar string to slice
+func splitDosenPengajar(listKelas []ListKelasResponse) {
 	for i := range listKelas {
 		if listKelas[i].IDDosenPengajarStr != "" {
 			listKelas[i].IDDosenPengajar = strings.Split(listKelas[i].IDDosenPengajarStr, "|")
 		} else {
 			listKelas[i].IDDosenPengajar = []string{}
 		}
-		// Initialize empty slice for jadwal_perkuliahan to prevent null in JSON
+	}
+}
+
+// attachJadwalPerkuliahan fetches jadwal_perkuliahan for every class in one query
+func (a *ApplicationServer) attachJadwalPerkuliahan(listKelas []ListKelasResponse) {
+	// Initialize empty slice for jadwal_perkuliahan to prevent null in JSON
+	for i := range listKelas {
 		listKelas[i].JadwalPerkuliahan = []JadwalPerkuliahan{}
 	}
 
@@ -713,23 +788,152 @@ func (a *ApplicationServer) ListKelasMisca(c *fiber.Ctx) error {
 			}
 		}
 	}
+}
 
-	// Create pagination info
-	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+// attachSchedules mengisi schedule setiap kelas dari tabel jadwal dalam satu query. Setiap baris jadwal membawa
+// ruangannya sendiri, berbeda dengan nama_ruangan yang diurutkan terpisah dari jadwal.
+func (a *ApplicationServer) attachSchedules(listKelas []ListKelasResponse, roomNameColumn, lang string) error {
+	kelasIDs := make([]string, 0, len(listKelas))
+	for _, kelas := range listKelas {
+		kelasIDs = append(kelasIDs, kelas.IDKelas)
+	}
+
+	schedules, err := a.findClassSchedules(kelasIDs, roomNameColumn)
 	if err != nil {
-		return HandleError(c, err)
+		return err
 	}
-	// Return result as JSON
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListKelasResponse]]{
-		Code:    fiber.StatusOK,
-		Status:  http.StatusText(fiber.StatusOK),
-		Success: true,
-		Message: "Sukses mendapatkan data kelas",
-		Data: ListDataApiResponseWrapper[ListKelasResponse]{
-			List:     listKelas,
-			PageInfo: pageInfo,
-		},
-	})
+
+	for i := range listKelas {
+		listKelas[i].Schedule = newKelasSchedules(schedules[listKelas[i].IDKelas], lang)
+	}
+
+	return nil
+}
+
+// buildStudentKelasDetailsMisca menyusun kelas_perkuliahan setiap mahasiswa dari baris yang sudah dinormalisasi:
+// mahasiswa x kelas (nilai), kelas x dosen (akt_mengajar_dosen), dan kelas x jadwal, digabung berdasarkan id kelas.
+func (a *ApplicationServer) buildStudentKelasDetailsMisca(models []ListStudentKelasModel, lang string) ([]ListStudentKelasResponse, error) {
+	responses := make([]ListStudentKelasResponse, 0, len(models))
+	if len(models) == 0 {
+		return responses, nil
+	}
+
+	idPDs := make([]string, 0, len(models))
+	semesters := make([]string, 0, 1)
+	for _, model := range models {
+		idPDs = append(idPDs, model.IDPesertaDidik)
+		if !slices.Contains(semesters, model.Semester) {
+			semesters = append(semesters, model.Semester)
+		}
+	}
+
+	rows := make([]studentKelasRow, 0)
+	err := a.db.Table("nilai").
+		Select(`
+			nilai.id_pd AS id_pd,
+			nilai.smt_ambil AS semester,
+			CAST(kelaskuliah.id_kls AS CHAR) AS id_kelas,
+			kelaskuliah.id_sms AS id_sms,
+			kelaskuliah.nm_kls AS nama_kelas,
+			matakuliah.nm_mk AS nama_matakuliah,
+			matakuliah.kode_mk AS kode_matakuliah
+		`).
+		Joins("JOIN kelaskuliah ON kelaskuliah.id_kls = nilai.id_kls").
+		Joins("JOIN matakuliah_kurikulum ON matakuliah_kurikulum.id_mk_kur = kelaskuliah.id_mk_kur").
+		Joins("JOIN matakuliah ON matakuliah.id_mk = matakuliah_kurikulum.id_mk").
+		Where("nilai.id_pd IN ? AND nilai.smt_ambil IN ?", idPDs, semesters).
+		Order("nilai.id_pd ASC, kelaskuliah.id_kls ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	kelasIDs := make([]string, 0, len(rows))
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if !seen[row.IDKelas] {
+			seen[row.IDKelas] = true
+			kelasIDs = append(kelasIDs, row.IDKelas)
+		}
+	}
+
+	lecturers := make([]kelasLecturerRow, 0)
+	if len(kelasIDs) > 0 {
+		err = a.db.Table("akt_mengajar_dosen").
+			Select("DISTINCT CAST(id_kls AS CHAR) AS id_kls, CAST(id_ptk AS CHAR) AS id_ptk").
+			Where("id_kls IN ? AND id_ptk IS NOT NULL", kelasIDs).
+			Order("id_kls ASC, id_ptk ASC").
+			Scan(&lecturers).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lecturersByKelas := make(map[string][]string, len(kelasIDs))
+	for _, lecturer := range lecturers {
+		lecturersByKelas[lecturer.IDKelas] = append(lecturersByKelas[lecturer.IDKelas], lecturer.IDPTK)
+	}
+
+	schedules, err := a.findClassSchedules(kelasIDs, "ruangan.nama_ruangan")
+	if err != nil {
+		return nil, err
+	}
+
+	kelasByStudent := make(map[string][]KelasPerkuliahan, len(models))
+	for _, row := range rows {
+		kelas := row.KelasPerkuliahan
+		kelas.DosenPengajar = lecturersByKelas[kelas.IDKelas]
+		if kelas.DosenPengajar == nil {
+			kelas.DosenPengajar = []string{}
+		}
+		kelas.IDDosenPengajar = strings.Join(kelas.DosenPengajar, "|")
+		kelas.Schedule = newKelasSchedules(schedules[kelas.IDKelas], lang)
+		kelas.Jadwal = legacyJadwal(schedules[kelas.IDKelas])
+
+		key := row.IDPesertaDidik + "|" + row.Semester
+		kelasByStudent[key] = append(kelasByStudent[key], kelas)
+	}
+
+	for _, model := range models {
+		kelasPerkuliahan := kelasByStudent[model.IDPesertaDidik+"|"+model.Semester]
+		if kelasPerkuliahan == nil {
+			kelasPerkuliahan = []KelasPerkuliahan{}
+		}
+
+		responses = append(responses, ListStudentKelasResponse{
+			IDPesertaDidik:   model.IDPesertaDidik,
+			IDMahasiswa:      model.IDMahasiswa,
+			Nik:              model.NIK,
+			Semester:         model.Semester,
+			KelasPerkuliahan: kelasPerkuliahan,
+		})
+	}
+
+	return responses, nil
+}
+
+// legacyJadwal menyusun string jadwal lama "Senin-08:00:00-10:00:00|..." dari jadwal satu kelas.
+func legacyJadwal(rows []ClassSchedule) string {
+	jadwal := make([]string, 0, len(rows))
+	for _, row := range rows {
+		jadwal = append(jadwal, gl.NamaHari(row.Hari)+"-"+row.JamMulai+"-"+row.JamSelesai)
+	}
+	return strings.Join(jadwal, "|")
+}
+
+func newKelasSchedules(rows []ClassSchedule, lang string) []KelasSchedule {
+	schedules := make([]KelasSchedule, 0, len(rows))
+	for _, row := range rows {
+		schedules = append(schedules, KelasSchedule{
+			DayOfWeek: row.Hari,
+			DayName:   gl.DayName(row.Hari, lang),
+			Start:     row.JamMulai,
+			End:       row.JamSelesai,
+			RoomID:    row.IDRuangan,
+			RoomName:  row.NamaRuangan,
+		})
+	}
+	return schedules
 }
 
 func (a *ApplicationServer) TotalKelasSmart(c *fiber.Ctx) error {
@@ -765,10 +969,71 @@ func (a *ApplicationServer) ListKelasSmart(c *fiber.Ctx) error {
 		return HandleError(c, err)
 	}
 
+	// Model to hold query results
+	listKelas := make([]ListKelasResponse, 0)
+
+	offset := req.Filter.GetOffset()
+	limit := req.Filter.GetLimit()
+
+	q, syncInfo, err := a.listKelasQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// Count total data
+	var totalData int64
+	if err := q.Count(&totalData).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	// Add limit and offset
+	q = q.Offset(int(offset)).Limit(int(limit))
+
+	// Execute query
+	if err := q.Scan(&listKelas).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	// Post-process id_dosen_pengajar to convert pipe-separated string to slice
+	splitDosenPengajar(listKelas)
+	if err := a.completeTruncatedKelas(listKelas, "akt_ajar_dosen", "id_reg_ptk"); err != nil {
+		return HandleError(c, err)
+	}
+	if err := a.attachSchedules(listKelas, "ruangan.kode_ruangan", RequestLang(c)); err != nil {
+		return HandleError(c, err)
+	}
+
+	// Create pagination info
+	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// Return result as CSV/XLSX when requested
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, kelasTable, listKelas, pageInfo)
+	}
+
+	// Return result as JSON
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListKelasResponse]]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data kelas",
+		Data: ListDataApiResponseWrapper[ListKelasResponse]{
+			List:     listKelas,
+			PageInfo: pageInfo,
+			Sync:     syncInfo,
+		},
+	})
+}
+
+// listKelasQuerySmart membangun query list kelas (semester, filter, sync dan sorting) tanpa paginasi
+func (a *ApplicationServer) listKelasQuerySmart(req *ListStudentKelasRequest) (*gorm.DB, *gl.SyncInfo, error) {
 	var activeSemester string
 	err := a.db.Table("semester").Select(`id_smt`).Where("a_periode_aktif = 1").Scan(&activeSemester).Error
 	if err != nil {
-		return HandleError(c, err)
+		return nil, nil, err
 	}
 
 	// Set default semester
@@ -776,14 +1041,60 @@ func (a *ApplicationServer) ListKelasSmart(c *fiber.Ctx) error {
 		req.Semester = activeSemester
 	}
 
-	// Model to hold query results
-	listKelas := make([]ListKelasResponse, 0)
+	// Query utama
+	q := a.kelasQuerySmart().Where("kelas_kuliah.id_smt = ?", req.Semester)
 
-	offset := req.Filter.GetOffset()
-	limit := req.Filter.GetLimit()
+	// Add keyword search
+	if req.Filter.HasKeyword() {
+		q = q.Where("kelas_kuliah.nm_kls LIKE ?", "%"+req.Filter.Keyword+"%")
+	}
 
-	// Query utama
-	q := a.db.
+	// tabel kelas_kuliah Smart tidak memiliki timestamp, selalu snapshot penuh
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Add sorting
+	if req.Filter.HasSort() {
+		q = q.Order(clause.OrderByColumn{
+			Column: clause.Column{Name: req.Filter.SortBy},
+			Desc:   req.Filter.IsDesc(),
+		})
+	} else {
+		q = q.Order("kelas_kuliah.id_kls ASC")
+	}
+
+	return q, syncInfo, nil
+}
+
+func (a *ApplicationServer) GetKelasSmart(c *fiber.Ctx) error {
+	kelas, err := findOne[ListKelasResponse](a.kelasQuerySmart().Where("kelas_kuliah.id_kls = ?", c.Params("id")), "Kelas tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	listKelas := []ListKelasResponse{kelas}
+	splitDosenPengajar(listKelas)
+	if err := a.completeTruncatedKelas(listKelas, "akt_ajar_dosen", "id_reg_ptk"); err != nil {
+		return HandleError(c, err)
+	}
+	if err := a.attachSchedules(listKelas, "ruangan.kode_ruangan", RequestLang(c)); err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListKelasResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data kelas",
+		Data:    listKelas[0],
+	})
+}
+
+// kelasQuerySmart adalah query dasar kelas (tanpa filter semester) yang dipakai oleh list dan detail
+func (a *ApplicationServer) kelasQuerySmart() *gorm.DB {
+	return a.db.
 		Table("kelas_kuliah").
 		Select(`
 			kelas_kuliah.id_kls AS id_kelas,
@@ -836,78 +1147,77 @@ func (a *ApplicationServer) ListKelasSmart(c *fiber.Ctx) error {
 		Joins("LEFT JOIN akt_ajar_dosen ON akt_ajar_dosen.id_kls = kelas_kuliah.id_kls").
 		Joins("LEFT JOIN jadwal ON jadwal.id_kls = kelas_kuliah.id_kls").
 		Joins("LEFT JOIN ruangan ON ruangan.id_ruangan = jadwal.id_ruangan").
-		Where("kelas_kuliah.id_smt = ?", req.Semester).
-		Debug().
 		Group("kelas_kuliah.id_kls")
+}
 
-	// Add keyword search
-	if req.Filter.HasKeyword() {
-		q = q.Where("kelas_kuliah.nm_kls LIKE ?", "%"+req.Filter.Keyword+"%")
+// smart
+func (a *ApplicationServer) ListSimpleStudentKelasSmart(c *fiber.Ctx) error {
+	req := NewListKelasRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
 	}
 
-	// Add sorting
-	if req.Filter.HasSort() {
-		q = q.Order(clause.OrderByColumn{
-			Column: clause.Column{Name: req.Filter.SortBy},
-			Desc:   req.Filter.IsDesc(),
-		})
-	} else {
-		q = q.Order("kelas_kuliah.id_kls ASC")
+	// Model untuk menampung hasil query
+	listKelas := make([]ListSimpleStudentKelas, 0)
+
+	offset := req.Filter.GetOffset()
+	limit := req.Filter.GetLimit()
+
+	q, syncInfo, err := a.listSimpleStudentKelasQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
 	}
 
-	// Count total data
+	// Menghitung jumlah total data
 	var totalData int64
 	if err := q.Count(&totalData).Error; err != nil {
 		return HandleError(c, err)
 	}
 
-	// Add limit and offset
+	// Menambahkan limit dan offset
 	q = q.Offset(int(offset)).Limit(int(limit))
 
-	// Execute query
+	// Eksekusi query
 	if err := q.Scan(&listKelas).Error; err != nil {
 		return HandleError(c, err)
 	}
 
-	// Post-process id_dosen_pengajar to convert pipe-separated string to slice
-	for i := range listKelas {
-		if listKelas[i].IDDosenPengajarStr != "" {
-			listKelas[i].IDDosenPengajar = strings.Split(listKelas[i].IDDosenPengajarStr, "|")
-		} else {
-			listKelas[i].IDDosenPengajar = []string{}
-		}
+	// id_kelas yang terpotong group_concat_max_len diambil ulang
+	if err := a.completeSimpleStudentKelas(listKelas, simpleStudentKelasIDsQuerySmart); err != nil {
+		return HandleError(c, err)
 	}
 
-	// Create pagination info
+	// Membuat informasi paginasi
 	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
 	if err != nil {
 		return HandleError(c, err)
 	}
 
-	// Return result as JSON
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListKelasResponse]]{
+	// Mengembalikan hasil sebagai CSV/XLSX jika diminta
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, simpleStudentKelasTable, listKelas, pageInfo)
+	}
+
+	// Mengembalikan hasil sebagai JSON
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListSimpleStudentKelas]]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
 		Success: true,
-		Message: "Sukses mendapatkan data kelas",
-		Data: ListDataApiResponseWrapper[ListKelasResponse]{
+		Message: "Sukses mendapatkan data kelas sederhana",
+		Data: ListDataApiResponseWrapper[ListSimpleStudentKelas]{
 			List:     listKelas,
 			PageInfo: pageInfo,
+			Sync:     syncInfo,
 		},
 	})
 }
 
-// smart
-func (a *ApplicationServer) ListSimpleStudentKelasSmart(c *fiber.Ctx) error {
-	req := NewListKelasRequest()
-	if err := c.QueryParser(req); err != nil {
-		return HandleError(c, err)
-	}
-
+// listSimpleStudentKelasQuerySmart membangun query kelas sederhana per mahasiswa (semester, filter, sync dan sorting) tanpa paginasi
+func (a *ApplicationServer) listSimpleStudentKelasQuerySmart(req *ListStudentKelasRequest) (*gorm.DB, *gl.SyncInfo, error) {
 	var activeSemester string
 	err := a.db.Table("semester").Select(`id_smt`).Where("a_periode_aktif = 1").Scan(&activeSemester).Error
 	if err != nil {
-		return HandleError(c, err)
+		return nil, nil, err
 	}
 
 	// Set default semester
@@ -915,12 +1225,6 @@ func (a *ApplicationServer) ListSimpleStudentKelasSmart(c *fiber.Ctx) error {
 		req.Semester = activeSemester
 	}
 
-	// Model untuk menampung hasil query
-	listKelas := make([]ListSimpleStudentKelas, 0)
-
-	offset := req.Filter.GetOffset()
-	limit := req.Filter.GetLimit()
-
 	// Query hanya mengambil kolom yang diperlukan
 	q := a.db.Table("nilai").
 		Select(`
@@ -940,6 +1244,12 @@ func (a *ApplicationServer) ListSimpleStudentKelasSmart(c *fiber.Ctx) error {
 		q = q.Where("mahasiswa.nik LIKE ?", "%"+req.Filter.Keyword+"%")
 	}
 
+	// tabel nilai dan mahasiswa Smart tidak memiliki timestamp, selalu snapshot penuh
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Sorting default atau sesuai request
 	if req.Filter.HasSort() {
 		q = q.Order(clause.OrderByColumn{
@@ -950,37 +1260,7 @@ func (a *ApplicationServer) ListSimpleStudentKelasSmart(c *fiber.Ctx) error {
 		q = q.Order("nilai.id_reg_pd ASC")
 	}
 
-	// Menghitung jumlah total data
-	var totalData int64
-	if err := q.Count(&totalData).Error; err != nil {
-		return HandleError(c, err)
-	}
-
-	// Menambahkan limit dan offset
-	q = q.Offset(int(offset)).Limit(int(limit))
-
-	// Eksekusi query
-	if err := q.Scan(&listKelas).Error; err != nil {
-		return HandleError(c, err)
-	}
-
-	// Membuat informasi paginasi
-	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
-	if err != nil {
-		return HandleError(c, err)
-	}
-
-	// Mengembalikan hasil sebagai JSON
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListSimpleStudentKelas]]{
-		Code:    fiber.StatusOK,
-		Status:  http.StatusText(fiber.StatusOK),
-		Success: true,
-		Message: "Sukses mendapatkan data kelas sederhana",
-		Data: ListDataApiResponseWrapper[ListSimpleStudentKelas]{
-			List:     listKelas,
-			PageInfo: pageInfo,
-		},
-	})
+	return q, syncInfo, nil
 }
 
 func (a *ApplicationServer) TotalListSimpleStudentKelasSmart(c *fiber.Ctx) error {
@@ -1022,3 +1302,203 @@ func (a *ApplicationServer) TotalListSimpleStudentKelasSmart(c *fiber.Ctx) error
 		},
 	})
 }
+
+func (a *ApplicationServer) ExportSimpleStudentKelasMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ExportSimpleStudentKelasSmart(c)
+	}
+
+	req := NewListKelasRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listSimpleStudentKelasQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, func(list []ListSimpleStudentKelas) ([]ListSimpleStudentKelas, error) {
+		return list, a.completeSimpleStudentKelas(list, simpleStudentKelasIDsQueryMisca)
+	})
+}
+
+func (a *ApplicationServer) ExportSimpleStudentKelasSmart(c *fiber.Ctx) error {
+	req := NewListKelasRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listSimpleStudentKelasQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, func(list []ListSimpleStudentKelas) ([]ListSimpleStudentKelas, error) {
+		return list, a.completeSimpleStudentKelas(list, simpleStudentKelasIDsQuerySmart)
+	})
+}
+
+func (a *ApplicationServer) ExportStudentKelasDetailsMisca(c *fiber.Ctx) error {
+	req := NewListKelasRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listStudentKelasDetailsQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// transform dijalankan setelah handler selesai, c tidak boleh dipakai di dalamnya
+	lang := RequestLang(c)
+	return StreamNDJSON(c, q, syncInfo, func(models []ListStudentKelasModel) ([]ListStudentKelasResponse, error) {
+		return a.buildStudentKelasDetailsMisca(models, lang)
+	})
+}
+
+func (a *ApplicationServer) ExportKelasMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ExportKelasSmart(c)
+	}
+
+	req := NewListKelasRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listKelasQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// transform dijalankan setelah handler selesai, c tidak boleh dipakai di dalamnya
+	lang := RequestLang(c)
+	return StreamNDJSON(c, q, syncInfo, func(listKelas []ListKelasResponse) ([]ListKelasResponse, error) {
+		splitDosenPengajar(listKelas)
+		if err := a.completeTruncatedKelas(listKelas, "akt_mengajar_dosen", "id_ptk"); err != nil {
+			return nil, err
+		}
+		a.attachJadwalPerkuliahan(listKelas)
+		return listKelas, a.attachSchedules(listKelas, "ruangan.nama_ruangan", lang)
+	})
+}
+
+func (a *ApplicationServer) ExportKelasSmart(c *fiber.Ctx) error {
+	req := NewListKelasRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listKelasQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	// transform dijalankan setelah handler selesai, c tidak boleh dipakai di dalamnya
+	lang := RequestLang(c)
+	return StreamNDJSON(c, q, syncInfo, func(listKelas []ListKelasResponse) ([]ListKelasResponse, error) {
+		splitDosenPengajar(listKelas)
+		if err := a.completeTruncatedKelas(listKelas, "akt_ajar_dosen", "id_reg_ptk"); err != nil {
+			return nil, err
+		}
+		return listKelas, a.attachSchedules(listKelas, "ruangan.kode_ruangan", lang)
+	})
+}
+
+var simpleStudentKelasTable = TabularTable[ListSimpleStudentKelas]{
+	Name: "kelas_mahasiswa",
+	Columns: []TabularColumn{
+		{HeaderID: "ID Peserta Didik", HeaderEN: "Student Registration ID"},
+		{HeaderID: "ID Mahasiswa", HeaderEN: "Student ID"},
+		{HeaderID: "NIK", HeaderEN: "NIK"},
+		{HeaderID: "ID Kelas", HeaderEN: "Class IDs"},
+		{HeaderID: "Semester", HeaderEN: "Semester"},
+	},
+	Rows: func(k ListSimpleStudentKelas) [][]string {
+		return [][]string{{k.IDPd, k.IDMahasiswa, k.NIK, strings.ReplaceAll(k.IDKelas, "|", ","), k.Semester}}
+	},
+}
+
+// studentKelasDetailsTable meratakan kelas_perkuliahan menjadi satu baris per kelas mahasiswa
+var studentKelasDetailsTable = TabularTable[ListStudentKelasResponse]{
+	Name: "detail_kelas_mahasiswa",
+	Columns: []TabularColumn{
+		{HeaderID: "ID Peserta Didik", HeaderEN: "Student Registration ID"},
+		{HeaderID: "ID Mahasiswa", HeaderEN: "Student ID"},
+		{HeaderID: "NIK", HeaderEN: "NIK"},
+		{HeaderID: "Semester", HeaderEN: "Semester"},
+		{HeaderID: "ID Kelas", HeaderEN: "Class ID"},
+		{HeaderID: "ID Prodi", HeaderEN: "Study Program ID"},
+		{HeaderID: "Nama Kelas", HeaderEN: "Class Name"},
+		{HeaderID: "Nama Mata Kuliah", HeaderEN: "Course Name"},
+		{HeaderID: "Kode Mata Kuliah", HeaderEN: "Course Code"},
+		{HeaderID: "ID Dosen Pengajar", HeaderEN: "Lecturer IDs"},
+		{HeaderID: "Jadwal", HeaderEN: "Schedule"},
+	},
+	Rows: func(s ListStudentKelasResponse) [][]string {
+		rows := make([][]string, 0, len(s.KelasPerkuliahan))
+		for _, k := range s.KelasPerkuliahan {
+			rows = append(rows, []string{
+				s.IDPesertaDidik, s.IDMahasiswa, s.Nik, s.Semester,
+				k.IDKelas, k.IDSMS, k.NamaKelas, k.NamaMatakuliah, k.KodeMatakuliah, strings.Join(k.DosenPengajar, ","), k.Jadwal,
+			})
+		}
+		return rows
+	},
+}
+
+// kelasTable meratakan jadwal_perkuliahan menjadi satu baris per pertemuan,
+// kelas tanpa jadwal perkuliahan tetap ditulis satu baris dengan kolom pertemuan kosong
+var kelasTable = TabularTable[ListKelasResponse]{
+	Name: "kelas",
+	Columns: []TabularColumn{
+		{HeaderID: "ID Kelas", HeaderEN: "Class ID"},
+		{HeaderID: "ID Prodi", HeaderEN: "Study Program ID"},
+		{HeaderID: "Nama Kelas", HeaderEN: "Class Name"},
+		{HeaderID: "Nama Mata Kuliah", HeaderEN: "Course Name"},
+		{HeaderID: "Kode Mata Kuliah", HeaderEN: "Course Code"},
+		{HeaderID: "ID Dosen Pengajar", HeaderEN: "Lecturer IDs"},
+		{HeaderID: "Semester", HeaderEN: "Semester"},
+		{HeaderID: "Jadwal", HeaderEN: "Schedule"},
+		{HeaderID: "Nama Ruangan", HeaderEN: "Room Name"},
+		{HeaderID: "Total Pertemuan", HeaderEN: "Total Meetings"},
+		{HeaderID: "Sesi", HeaderEN: "Session"},
+		{HeaderID: "Tanggal", HeaderEN: "Date"},
+		{HeaderID: "Jam Mulai", HeaderEN: "Start Time"},
+		{HeaderID: "Jam Selesai", HeaderEN: "End Time"},
+		{HeaderID: "Metode Pembelajaran", HeaderEN: "Learning Method"},
+		{HeaderID: "Jenis Pertemuan", HeaderEN: "Meeting Type"},
+		{HeaderID: "ID Ruangan Pertemuan", HeaderEN: "Meeting Room ID"},
+		{HeaderID: "URL", HeaderEN: "URL"},
+		{HeaderID: "Status", HeaderEN: "Status"},
+	},
+	Rows: func(k ListKelasResponse) [][]string {
+		kelas := []string{
+			k.IDKelas, k.IDSMS, k.NamaKelas, k.NamaMataKuliah, k.KodeMataKuliah,
+			strings.Join(k.IDDosenPengajar, ","), k.Semester, k.Jadwal, k.NamaRuangan, k.TotalPertemuan,
+		}
+
+		if len(k.JadwalPerkuliahan) == 0 {
+			return [][]string{append(kelas, "", "", "", "", "", "", "", "", "")}
+		}
+
+		rows := make([][]string, 0, len(k.JadwalPerkuliahan))
+		for _, j := range k.JadwalPerkuliahan {
+			row := append([]string{}, kelas...)
+			row = append(row,
+				strconv.FormatInt(j.Sesi, 10),
+				j.Tanggal.Format(time.DateOnly),
+				j.JamMulai,
+				j.JamSelesai,
+				j.MetodePembelajaran,
+				j.JenisPertemuan,
+				tabularInt(j.IDRuangan),
+				tabularString(j.URL),
+				j.Status,
+			)
+			rows = append(rows, row)
+		}
+		return rows
+	},
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	gl "lab.garudacyber.co.id/g-learning-connector"
 )
@@ -15,6 +16,7 @@ type (
 
 	ListLecturerRequest struct {
 		gl.Filter
+		gl.SyncFilter
 	}
 
 	ListLecturerResponse struct {
@@ -49,23 +51,9 @@ func (a *ApplicationServer) ListLecturerMisca(c *fiber.Ctx) error {
 	offset := req.Filter.GetOffset()
 	limit := req.Filter.GetLimit()
 
-	q := a.db.Select(`id_ptk, nama_dosen, jenis_kelamin, nik, email, handphone, telepon`).
-		Table("dosen").
-		Where("nik IS NOT NULL AND nik != '' AND LENGTH(nik) = 16")
-
-	if req.Filter.HasKeyword() {
-		q = q.Where("nama_dosen LIKE ? OR nik LIKE ?", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
-	}
-
-	if req.Filter.HasSort() {
-		q = q.Order(
-			clause.OrderByColumn{
-				Column: clause.Column{Name: req.Filter.SortBy},
-				Desc:   req.Filter.IsDesc(),
-			},
-		)
-	} else {
-		q = q.Order("created_at ASC")
+	q, syncInfo, err := a.listLecturerQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
 	}
 
 	// Menghitung jumlah total data tanpa offset dan limit
@@ -86,6 +74,10 @@ func (a *ApplicationServer) ListLecturerMisca(c *fiber.Ctx) error {
 		return HandleError(c, err)
 	}
 
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, lecturerTable, listLecturer, pageInfo)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListLecturerResponse]]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
@@ -94,10 +86,64 @@ func (a *ApplicationServer) ListLecturerMisca(c *fiber.Ctx) error {
 		Data: ListDataApiResponseWrapper[ListLecturerResponse]{
 			List:     listLecturer,
 			PageInfo: pageInfo,
+			Sync:     syncInfo,
 		},
 	})
 }
 
+// listLecturerQueryMisca membangun query list dosen (filter, sync dan sorting) tanpa paginasi
+func (a *ApplicationServer) listLecturerQueryMisca(req *ListLecturerRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	q := a.lecturersQueryMisca()
+
+	if req.Filter.HasKeyword() {
+		q = q.Where("nama_dosen LIKE ? OR nik LIKE ?", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	}
+
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, lecturersSyncConditionsMisca)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.Filter.HasSort() {
+		q = q.Order(
+			clause.OrderByColumn{
+				Column: clause.Column{Name: req.Filter.SortBy},
+				Desc:   req.Filter.IsDesc(),
+			},
+		)
+	} else {
+		q = q.Order("created_at ASC")
+	}
+
+	return q, syncInfo, nil
+}
+
+// lecturersQueryMisca adalah query dasar dosen yang dipakai oleh list dan detail
+func (a *ApplicationServer) lecturersQueryMisca() *gorm.DB {
+	return a.db.Select(`id_ptk, nama_dosen, jenis_kelamin, nik, email, handphone, telepon`).
+		Table("dosen").
+		Where("nik IS NOT NULL AND nik != '' AND LENGTH(nik) = 16")
+}
+
+func (a *ApplicationServer) GetLecturerMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetLecturerSmart(c)
+	}
+
+	lecturer, err := findOne[ListLecturerResponse](a.lecturersQueryMisca().Where("id_ptk = ?", c.Params("id")), "Dosen tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListLecturerResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data dosen",
+		Data:    lecturer,
+	})
+}
+
 func (a *ApplicationServer) GetTotalLecturerMisca(c *fiber.Ctx) error {
 	if IsSmartInstansi(c) {
 		return a.GetTotalLecturerSmart(c)
@@ -135,13 +181,9 @@ func (a *ApplicationServer) ListLecturerSmart(c *fiber.Ctx) error {
 	offset := req.Filter.GetOffset()
 	limit := req.Filter.GetLimit()
 
-	q := a.db.
-		Select(`id_ptk, nm_ptk AS nama_dosen, jk AS jenis_kelamin, nik, email, no_hp AS handphone, no_tel_rmh AS telephone`).
-		Table("dosen").
-		Where("nik IS NOT NULL AND nik != '' AND LENGTH(nik) = 16")
-
-	if req.Filter.HasKeyword() {
-		q = q.Where("nama_dosen LIKE ? OR nik LIKE ?", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	q, syncInfo, err := a.listLecturerQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
 	}
 
 	// Menghitung jumlah total data tanpa offset dan limit
@@ -162,6 +204,10 @@ func (a *ApplicationServer) ListLecturerSmart(c *fiber.Ctx) error {
 		return HandleError(c, err)
 	}
 
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, lecturerTable, listLecturer, pageInfo)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListLecturerResponse]]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
@@ -170,10 +216,51 @@ func (a *ApplicationServer) ListLecturerSmart(c *fiber.Ctx) error {
 		Data: ListDataApiResponseWrapper[ListLecturerResponse]{
 			List:     listLecturer,
 			PageInfo: pageInfo,
+			Sync:     syncInfo,
 		},
 	})
 }
 
+// listLecturerQuerySmart membangun query list dosen (filter dan sync) tanpa paginasi
+func (a *ApplicationServer) listLecturerQuerySmart(req *ListLecturerRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	q := a.lecturersQuerySmart()
+
+	if req.Filter.HasKeyword() {
+		q = q.Where("nama_dosen LIKE ? OR nik LIKE ?", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	}
+
+	// tabel dosen Smart tidak memiliki timestamp, selalu snapshot penuh
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return q, syncInfo, nil
+}
+
+// lecturersQuerySmart adalah query dasar dosen yang dipakai oleh list dan detail
+func (a *ApplicationServer) lecturersQuerySmart() *gorm.DB {
+	return a.db.
+		Select(`id_ptk, nm_ptk AS nama_dosen, jk AS jenis_kelamin, nik, email, no_hp AS handphone, no_tel_rmh AS telepon`).
+		Table("dosen").
+		Where("nik IS NOT NULL AND nik != '' AND LENGTH(nik) = 16")
+}
+
+func (a *ApplicationServer) GetLecturerSmart(c *fiber.Ctx) error {
+	lecturer, err := findOne[ListLecturerResponse](a.lecturersQuerySmart().Where("id_ptk = ?", c.Params("id")), "Dosen tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListLecturerResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data dosen",
+		Data:    lecturer,
+	})
+}
+
 func (a *ApplicationServer) GetTotalLecturerSmart(c *fiber.Ctx) error {
 	var total int64
 
@@ -195,3 +282,51 @@ func (a *ApplicationServer) GetTotalLecturerSmart(c *fiber.Ctx) error {
 		},
 	})
 }
+
+func (a *ApplicationServer) ExportLecturerMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ExportLecturerSmart(c)
+	}
+
+	req := NewListLecturerRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listLecturerQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, exportIdentity[ListLecturerResponse])
+}
+
+func (a *ApplicationServer) ExportLecturerSmart(c *fiber.Ctx) error {
+	req := NewListLecturerRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listLecturerQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, exportIdentity[ListLecturerResponse])
+}
+
+var lecturerTable = TabularTable[ListLecturerResponse]{
+	Name: "dosen",
+	Columns: []TabularColumn{
+		{HeaderID: "ID", HeaderEN: "ID"},
+		{HeaderID: "Nama", HeaderEN: "Name"},
+		{HeaderID: "Jenis Kelamin", HeaderEN: "Gender"},
+		{HeaderID: "NIK", HeaderEN: "NIK"},
+		{HeaderID: "Email", HeaderEN: "Email"},
+		{HeaderID: "Handphone", HeaderEN: "Mobile Phone"},
+		{HeaderID: "Telepon", HeaderEN: "Telephone"},
+	},
+	Rows: func(l ListLecturerResponse) [][]string {
+		return [][]string{{l.ID, l.Name, l.Gender, l.NIK, l.Email, l.Handphone, l.Telephone}}
+	},
+}
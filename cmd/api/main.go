@@ -38,9 +38,14 @@ func main() {
 	})
 
 	app := NewApplicationServer(db, logger, config, router)
+	app.CheckConnectorTables()
 	app.SetupCommonMiddlewares()
 	app.SetupHealthCheckRoutes()
 	app.SetupRoutes()
+	app.SetupDocsRoutes()
+
+	go app.RunDeletionSnapshotRefresher()
+	go app.RunIdempotencyKeyPurger()
 
 	app.Run()
 }
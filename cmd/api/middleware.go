@@ -2,11 +2,14 @@ package main
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	gl "lab.garudacyber.co.id/g-learning-connector"
 )
 
 // example -> Authorization: Bearer jwtTokenXXX
@@ -17,8 +20,32 @@ const (
 	instansiTypeKey   = "tipe_instansi"
 	instansiTypeMisca = "MISCA"
 	instansiTypeSmart = "SMART"
+
+	// api key dengan param secret_smartthink_write boleh membaca dan menulis,
+	// api key secret_smartthink hanya boleh membaca
+	secretParam      = "secret_smartthink"
+	writeSecretParam = "secret_smartthink_write"
+
+	apiKeyPermissionKey   = "api_key_permission"
+	apiKeyPermissionRead  = "read"
+	apiKeyPermissionWrite = "write"
+
+	apiKeyFingerprintKey = "api_key_fingerprint"
+
+	apiV1Prefix = "/api/misca/"
+	apiV2Prefix = "/api/v2/"
 )
 
+// v2Resources memetakan resource /api/misca ke resource pengganti di /api/v2
+var v2Resources = map[string]string{
+	"semesters": "semesters",
+	"students":  "students",
+	"lecturers": "lecturers",
+	"classes":   "classes",
+	"rooms":     "rooms",
+	"sms":       "study-programs",
+}
+
 func (a *ApplicationServer) WithApiKey() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		authorizationHeader := strings.TrimSpace(ctx.Get(authorizationHeaderKey))
@@ -45,10 +72,11 @@ func (a *ApplicationServer) WithApiKey() fiber.Handler {
 
 		var secret string
 		var instansi string
+		settingTable := "setting_pt"
 
 		// Coba dulu ke setting_pt
 		err := a.db.Table("setting_pt").
-			Where("param = ?", "secret_smartthink").
+			Where("param = ?", secretParam).
 			Select("value").
 			Scan(&secret).Error
 
@@ -56,8 +84,9 @@ func (a *ApplicationServer) WithApiKey() fiber.Handler {
 			instansi = instansiTypeMisca
 		} else {
 			// Kalau tidak ada, fallback ke setting_app
+			settingTable = "setting_app"
 			err = a.db.Table("setting_app").
-				Where("param = ?", "secret_smartthink").
+				Where("param = ?", secretParam).
 				Select("value").
 				Scan(&secret).Error
 			if err != nil {
@@ -80,20 +109,93 @@ func (a *ApplicationServer) WithApiKey() fiber.Handler {
 			})
 		}
 
+		permission := apiKeyPermissionRead
 		if secret != apiKey {
-			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
-				"code":    http.StatusUnauthorized,
-				"status":  "Unauthorized",
+			var writeSecret string
+			err = a.db.Table(settingTable).
+				Where("param = ?", writeSecretParam).
+				Select("value").
+				Scan(&writeSecret).Error
+			if err != nil {
+				return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+					"code":    http.StatusInternalServerError,
+					"status":  "Internal Server Error",
+					"success": false,
+					"message": err.Error(),
+				})
+			}
+
+			if writeSecret == "" || writeSecret != apiKey {
+				return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+					"code":    http.StatusUnauthorized,
+					"status":  "Unauthorized",
+					"success": false,
+					"message": "Api key tidak sesuai",
+				})
+			}
+			permission = apiKeyPermissionWrite
+		}
+
+		ctx.Locals(instansiTypeKey, instansi)
+		ctx.Locals(apiKeyPermissionKey, permission)
+		ctx.Locals(apiKeyFingerprintKey, apiKeyFingerprint(apiKey))
+		return ctx.Next()
+	}
+}
+
+// WithWriteApiKey harus dipasang setelah WithApiKey pada endpoint yang mengubah data.
+func (a *ApplicationServer) WithWriteApiKey() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Locals(apiKeyPermissionKey) != apiKeyPermissionWrite {
+			return ctx.Status(http.StatusForbidden).JSON(fiber.Map{
+				"code":    http.StatusForbidden,
+				"status":  "Forbidden",
 				"success": false,
-				"message": "Api key tidak sesuai",
+				"message": "Api key tidak memiliki izin untuk mengubah data",
 			})
 		}
 
-		ctx.Locals(instansiTypeKey, instansi)
 		return ctx.Next()
 	}
 }
 
+// WithV1Deprecation menandai /api/misca sebagai v1 dengan header Deprecation (RFC 9745) dan Sunset (RFC 8594)
+// sesuai API_V1_DEPRECATED_AT dan API_V1_SUNSET_AT, serta Link ke koleksi v2 penggantinya jika sudah ada.
+func (a *ApplicationServer) WithV1Deprecation() fiber.Handler {
+	var deprecation, sunset string
+	if a.config.APIV1DeprecatedAt != "" {
+		deprecatedAt, err := time.Parse(time.DateOnly, a.config.APIV1DeprecatedAt)
+		gl.PanicIfNeeded(err)
+		deprecation = "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	}
+	if a.config.APIV1SunsetAt != "" {
+		sunsetAt, err := time.Parse(time.DateOnly, a.config.APIV1SunsetAt)
+		gl.PanicIfNeeded(err)
+		sunset = sunsetAt.Format(http.TimeFormat)
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		if deprecation != "" {
+			ctx.Set("Deprecation", deprecation)
+		}
+		if sunset != "" {
+			ctx.Set("Sunset", sunset)
+		}
+
+		resource, _, _ := strings.Cut(strings.TrimPrefix(ctx.Path(), apiV1Prefix), "/")
+		if successor, ok := v2Resources[resource]; ok {
+			ctx.Append(fiber.HeaderLink, "<"+apiV2Prefix+successor+`>; rel="successor-version"`)
+		}
+
+		return ctx.Next()
+	}
+}
+
+// apiKeyFingerprint dipakai untuk mencatat api key pada audit log tanpa menyimpan secret-nya.
+func apiKeyFingerprint(apiKey string) string {
+	return hashString(apiKey)[:16]
+}
+
 func (a *ApplicationServer) SetupCommonMiddlewares() {
 	a.router.Use(cors.New())
 	a.router.Use(recover.New())
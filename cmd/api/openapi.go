@@ -0,0 +1,506 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	openAPIVersion   = "3.0.3"
+	openAPIDocPath   = "/api/openapi.json"
+	openAPIDocsPath  = "/api/docs"
+	swaggerUICSSPath = openAPIDocsPath + "/swagger-ui.css"
+	swaggerUIJSPath  = openAPIDocsPath + "/swagger-ui-bundle.js"
+
+	securityApiKey        = "apiKey"
+	securityCalendarToken = "calendarToken"
+)
+
+// Aset Swagger UI di-embed (lihat swagger-ui/README.md) supaya /api/docs tidak bergantung pada script CDN.
+var (
+	//go:embed swagger-ui/swagger-ui.css
+	swaggerUICSS []byte
+	//go:embed swagger-ui/swagger-ui-bundle.js
+	swaggerUIJS []byte
+)
+
+var (
+	ErrRouteWithoutSpec   = errors.New("route belum memiliki entri pada apiOperations")
+	ErrSpecWithoutRoute   = errors.New("entri apiOperations tidak memiliki route")
+	ErrOpenAPIUnavailable = errors.New("dokumen OpenAPI gagal dibangun saat start")
+
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+type (
+	OpenAPIDocument struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       OpenAPIInfo                             `json:"info"`
+		Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+		Components OpenAPIComponents                       `json:"components"`
+	}
+
+	OpenAPIInfo struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Version     string `json:"version"`
+	}
+
+	OpenAPIComponents struct {
+		Schemas         map[string]*OpenAPISchema         `json:"schemas"`
+		SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes"`
+	}
+
+	OpenAPISecurityScheme struct {
+		Type        string `json:"type"`
+		Scheme      string `json:"scheme,omitempty"`
+		In          string `json:"in,omitempty"`
+		Name        string `json:"name,omitempty"`
+		Description string `json:"description,omitempty"`
+	}
+
+	OpenAPIOperation struct {
+		Tags        []string                    `json:"tags"`
+		Summary     string                      `json:"summary"`
+		Deprecated  bool                        `json:"deprecated,omitempty"`
+		Security    []map[string][]string       `json:"security,omitempty"`
+		Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*OpenAPIResponse `json:"responses"`
+	}
+
+	OpenAPIParameter struct {
+		Name        string         `json:"name"`
+		In          string         `json:"in"`
+		Description string         `json:"description,omitempty"`
+		Required    bool           `json:"required,omitempty"`
+		Schema      *OpenAPISchema `json:"schema"`
+	}
+
+	OpenAPIRequestBody struct {
+		Required bool                         `json:"required"`
+		Content  map[string]*OpenAPIMediaType `json:"content"`
+	}
+
+	OpenAPIResponse struct {
+		Description string                       `json:"description"`
+		Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+	}
+
+	OpenAPIMediaType struct {
+		Schema *OpenAPISchema `json:"schema"`
+	}
+
+	OpenAPISchema struct {
+		Ref                  string                    `json:"$ref,omitempty"`
+		AllOf                []*OpenAPISchema          `json:"allOf,omitempty"`
+		Type                 string                    `json:"type,omitempty"`
+		Format               string                    `json:"format,omitempty"`
+		Nullable             bool                      `json:"nullable,omitempty"`
+		Enum                 []string                  `json:"enum,omitempty"`
+		Items                *OpenAPISchema            `json:"items,omitempty"`
+		Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+		AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+		Required             []string                  `json:"required,omitempty"`
+	}
+
+	// openAPIBuilder mengubah tipe Go menjadi schema. Struct bernama disimpan di components, struct generik
+	// (ApiResponse[T], ListDataApiResponseWrapper[T]) dan anonim ditulis langsung di tempatnya.
+	openAPIBuilder struct {
+		schemas map[string]*OpenAPISchema
+	}
+)
+
+// SetupDocsRoutes harus dipanggil setelah SetupRoutes. Dokumen OpenAPI dibangun sekali dari route yang terdaftar
+// dan apiOperations. Route tanpa entri spec atau sebaliknya dicatat di log dan /api/openapi.json merespon 503,
+// kecocokannya dijaga oleh TestOpenAPIOperationsMatchRoutes.
+func (a *ApplicationServer) SetupDocsRoutes() {
+	var doc []byte
+	a.router.Get(openAPIDocPath, func(c *fiber.Ctx) error {
+		if doc == nil {
+			return HandleError(c, NewAppError(http.StatusServiceUnavailable, "Dokumen OpenAPI tidak tersedia", ErrOpenAPIUnavailable))
+		}
+
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(doc)
+	})
+	a.router.Get(openAPIDocsPath, func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(fmt.Sprintf(openAPIDocsHTML, html.EscapeString(a.config.AppName), swaggerUICSSPath, swaggerUIJSPath, openAPIDocPath))
+	})
+	a.router.Get(swaggerUICSSPath, func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/css; charset=utf-8")
+		return c.Send(swaggerUICSS)
+	})
+	a.router.Get(swaggerUIJSPath, func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/javascript; charset=utf-8")
+		return c.Send(swaggerUIJS)
+	})
+
+	spec, err := a.BuildOpenAPIDocument(apiOperations)
+	if err != nil {
+		a.logger.Error("failed to build openapi document", "error", err)
+		return
+	}
+
+	doc, err = json.Marshal(spec)
+	if err != nil {
+		a.logger.Error("failed to marshal openapi document", "error", err)
+	}
+}
+
+// BuildOpenAPIDocument membuat dokumen dari route table router. Setiap route (kecuali HEAD dan middleware Use)
+// harus memiliki entri pada operations dengan kunci "METHOD path", dan setiap entri harus memiliki route.
+func (a *ApplicationServer) BuildOpenAPIDocument(operations map[string]apiOperation) (*OpenAPIDocument, error) {
+	b := &openAPIBuilder{schemas: map[string]*OpenAPISchema{}}
+	b.schemas["ErrorResponse"] = b.schema(reflect.TypeOf(ApiResponse[struct{}]{}))
+
+	doc := &OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: OpenAPIInfo{
+			Title:       a.config.AppName,
+			Description: "Kontrak API G Learning Connector. /api/misca adalah v1 yang dibekukan, /api/v2 memakai satu skema untuk Misca dan Smart.",
+			Version:     "2",
+		},
+		Paths: map[string]map[string]*OpenAPIOperation{},
+		Components: OpenAPIComponents{
+			Schemas: b.schemas,
+			SecuritySchemes: map[string]*OpenAPISecurityScheme{
+				securityApiKey: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "Authorization: Bearer <secret_smartthink>, endpoint tulis membutuhkan secret_smartthink_write",
+				},
+				securityCalendarToken: {
+					Type:        "apiKey",
+					In:          "query",
+					Name:        calendarTokenQuery,
+					Description: "Token kalender dari POST /api/misca/calendar/tokens",
+				},
+			},
+		},
+	}
+
+	var missing []string
+	registered := map[string]bool{}
+	for _, route := range a.router.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		key := route.Method + " " + route.Path
+		op, ok := operations[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		registered[key] = true
+
+		path := route.Path
+		for _, param := range route.Params {
+			path = strings.Replace(path, ":"+param, "{"+param+"}", 1)
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*OpenAPIOperation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = b.operation(route, op, a.config.APIV1DeprecatedAt != "")
+	}
+
+	var stale []string
+	for key := range operations {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, errors.Wrap(ErrRouteWithoutSpec, strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		return nil, errors.Wrap(ErrSpecWithoutRoute, strings.Join(stale, ", "))
+	}
+
+	return doc, nil
+}
+
+func (b *openAPIBuilder) operation(route fiber.Route, op apiOperation, v1Deprecated bool) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		Tags:       []string{openAPITag(route.Path)},
+		Summary:    op.summary,
+		Deprecated: v1Deprecated && strings.HasPrefix(route.Path, apiV1Prefix),
+		Responses:  map[string]*OpenAPIResponse{},
+	}
+
+	switch op.auth {
+	case authApiKey, authWriteApiKey:
+		operation.Security = []map[string][]string{{securityApiKey: {}}}
+	case authCalendarToken:
+		operation.Security = []map[string][]string{{securityCalendarToken: {}}}
+	}
+
+	for _, param := range route.Params {
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   &OpenAPISchema{Type: "string"},
+		})
+	}
+	if op.query != nil {
+		for _, param := range b.queryParameters(reflect.TypeOf(op.query)) {
+			// export selalu mengirim seluruh data tanpa paginasi
+			if op.export && (param.Name == "current_page" || param.Name == "per_page") {
+				continue
+			}
+			operation.Parameters = append(operation.Parameters, param)
+		}
+	}
+	if op.tabular {
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name:        "format",
+			In:          "query",
+			Description: "csv atau xlsx, bisa juga lewat header Accept",
+			Schema:      &OpenAPISchema{Type: "string", Enum: []string{tabularFormatCSV, tabularFormatXLSX}},
+		})
+	}
+	if op.tabular || op.lang {
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name:        "lang",
+			In:          "query",
+			Description: "bahasa header spreadsheet dan nama hari/semester",
+			Schema:      &OpenAPISchema{Type: "string", Enum: []string{gl.LangID, gl.LangEN}},
+		})
+	}
+	if op.idempotent {
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name:        idempotencyKeyHeader,
+			In:          "header",
+			Description: "request ulang dengan key yang sama mendapat response yang tersimpan",
+			Schema:      &OpenAPISchema{Type: "string", Format: "uuid"},
+		})
+	}
+
+	if op.body != nil {
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content: map[string]*OpenAPIMediaType{
+				fiber.MIMEApplicationJSON: {Schema: b.schema(reflect.TypeOf(op.body))},
+			},
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	response := &OpenAPIResponse{Description: http.StatusText(status), Content: map[string]*OpenAPIMediaType{}}
+	switch {
+	case op.contentType != "":
+		response.Content[op.contentType] = &OpenAPIMediaType{Schema: &OpenAPISchema{Type: "string"}}
+	case op.export:
+		// setiap baris NDJSON adalah satu object response
+		response.Content[ndjsonContentType] = &OpenAPIMediaType{Schema: b.schema(reflect.TypeOf(op.response))}
+	default:
+		response.Content[fiber.MIMEApplicationJSON] = &OpenAPIMediaType{Schema: b.schema(reflect.TypeOf(op.response))}
+	}
+	if op.tabular {
+		response.Content[csvContentType] = &OpenAPIMediaType{Schema: &OpenAPISchema{Type: "string"}}
+		response.Content[xlsxContentType] = &OpenAPIMediaType{Schema: &OpenAPISchema{Type: "string", Format: "binary"}}
+	}
+	operation.Responses[fmt.Sprint(status)] = response
+
+	if op.auth != authNone {
+		operation.Responses["default"] = &OpenAPIResponse{
+			Description: "Error",
+			Content: map[string]*OpenAPIMediaType{
+				fiber.MIMEApplicationJSON: {Schema: &OpenAPISchema{Ref: "#/components/schemas/ErrorResponse"}},
+			},
+		}
+	}
+
+	return operation
+}
+
+// openAPITag mengelompokkan operasi per versi dan resource, contoh "v1 students" atau "v2 classes".
+func openAPITag(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	switch {
+	case segments[0] == "misca" && len(segments) > 1:
+		return "v1 " + segments[1]
+	case segments[0] == "v2" && len(segments) > 1:
+		return "v2 " + segments[1]
+	case segments[0] == "calendar":
+		return "calendar"
+	}
+	return "docs"
+}
+
+func (b *openAPIBuilder) queryParameters(t reflect.Type) []*OpenAPIParameter {
+	params := make([]*OpenAPIParameter, 0)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			params = append(params, b.queryParameters(field.Type)...)
+			continue
+		}
+
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		schema := b.schema(field.Type)
+		schema.Nullable = false
+		params = append(params, &OpenAPIParameter{
+			Name:     name,
+			In:       "query",
+			Required: validateRequired(field),
+			Schema:   schema,
+		})
+	}
+
+	return params
+}
+
+func (b *openAPIBuilder) schema(t reflect.Type) *OpenAPISchema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := b.schemaOf(t)
+	if !nullable {
+		return schema
+	}
+	if schema.Ref != "" {
+		return &OpenAPISchema{AllOf: []*OpenAPISchema{schema}, Nullable: true}
+	}
+	schema.Nullable = true
+	return schema
+}
+
+func (b *openAPIBuilder) schemaOf(t reflect.Type) *OpenAPISchema {
+	switch t {
+	case timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &OpenAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" || strings.Contains(name, "[") {
+			return b.object(t)
+		}
+
+		if _, ok := b.schemas[name]; !ok {
+			// didaftarkan sebelum field dibaca supaya tipe rekursif (SMSTreeNode) memakai $ref ke dirinya sendiri
+			placeholder := &OpenAPISchema{}
+			b.schemas[name] = placeholder
+			*placeholder = *b.object(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+
+	return &OpenAPISchema{}
+}
+
+func (b *openAPIBuilder) object(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	b.fields(t, schema)
+	return schema
+}
+
+// fields mengikuti aturan encoding/json: tag "-" dilewati, struct embedded tanpa nama diratakan.
+func (b *openAPIBuilder) fields(t reflect.Type, schema *OpenAPISchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			b.fields(fieldType, schema)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = b.schema(field.Type)
+		if validateRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func validateRequired(field reflect.StructField) bool {
+	rule, _, _ := strings.Cut(field.Tag.Get("validate"), ",")
+	return rule == "required"
+}
+
+const openAPIDocsHTML = `<!doctype html>
+<html lang="id">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>%s</title>
+	<link rel="stylesheet" href="%s">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="%s"></script>
+	<script>
+		window.ui = SwaggerUIBundle({url: "%s", dom_id: "#swagger-ui"});
+	</script>
+</body>
+</html>
+`
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"net/http"
+
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+type (
+	apiAuth int
+
+	// apiOperation adalah entri spec untuk satu route. query dibaca dari tag query, body dari tag json, response
+	// adalah isi body 200 (untuk export, satu baris NDJSON).
+	apiOperation struct {
+		summary     string
+		auth        apiAuth
+		query       any
+		body        any
+		response    any
+		status      int
+		export      bool
+		tabular     bool
+		lang        bool
+		idempotent  bool
+		contentType string
+	}
+
+	// pageQuery, semesterQuery, dan tipePenilaianQuery mewakili parameter yang tidak dibaca dengan struct request
+	// milik handler-nya sendiri
+	pageQuery struct {
+		gl.Filter
+		gl.SyncFilter
+	}
+
+	semesterQuery struct {
+		Semester string `query:"semester"`
+	}
+
+	tipePenilaianQuery struct {
+		TipePenilaian string `query:"tipe_penilaian"`
+	}
+)
+
+const (
+	authNone apiAuth = iota
+	authApiKey
+	authWriteApiKey
+	authCalendarToken
+)
+
+func listOperation[T any](summary string, query any) apiOperation {
+	return apiOperation{summary: summary, auth: authApiKey, query: query, response: ApiResponse[ListDataApiResponseWrapper[T]]{}}
+}
+
+func itemOperation[T any](summary string, query any) apiOperation {
+	return apiOperation{summary: summary, auth: authApiKey, query: query, response: ApiResponse[T]{}}
+}
+
+func exportOperation[T any](summary string, query any) apiOperation {
+	return apiOperation{summary: summary, auth: authApiKey, query: query, response: *new(T), export: true}
+}
+
+func writeOperation[T any](summary string, body any) apiOperation {
+	return apiOperation{summary: summary, auth: authWriteApiKey, body: body, response: ApiResponse[T]{}}
+}
+
+func tabular(op apiOperation) apiOperation {
+	op.tabular = true
+	return op
+}
+
+func withLang(op apiOperation) apiOperation {
+	op.lang = true
+	return op
+}
+
+func calendarFeedOperation(summary string) apiOperation {
+	return apiOperation{summary: summary, auth: authCalendarToken, query: CalendarFeedRequest{}, contentType: "text/calendar"}
+}
+
+// apiOperations wajib memiliki satu entri untuk setiap route pada SetupRoutes dan SetupDocsRoutes dengan kunci
+// "METHOD path" persis seperti saat didaftarkan, jika tidak aplikasi berhenti saat start.
+var apiOperations = map[string]apiOperation{
+	"GET /api/misca/semesters":           tabular(listOperation[ListSemestersResponse]("List semester", ListSemestersRequest{})),
+	"GET /api/misca/semesters/active":    withLang(itemOperation[ListSemestersResponse]("Semester aktif", nil)),
+	"GET /api/misca/semesters/deletions": listOperation[DeletedRecord]("Semester yang terhapus", ListDeletionsRequest{}),
+	"GET /api/misca/semesters/export":    withLang(exportOperation[ListSemestersResponse]("Export semester (NDJSON)", ListSemestersRequest{})),
+	"GET /api/misca/semesters/:id":       withLang(itemOperation[ListSemestersResponse]("Detail semester", nil)),
+
+	"GET /api/misca/students":             tabular(listOperation[ListStudentsResponse]("List mahasiswa", ListStudentsRequest{})),
+	"GET /api/misca/students/total":       itemOperation[GetTotalStudentsResponse]("Total mahasiswa", nil),
+	"GET /api/misca/students/deletions":   listOperation[DeletedRecord]("Mahasiswa yang terhapus", ListDeletionsRequest{}),
+	"GET /api/misca/students/export":      exportOperation[ListStudentsResponse]("Export mahasiswa (NDJSON)", ListStudentsRequest{}),
+	"GET /api/misca/students/by-nik/:nik": itemOperation[ListStudentsResponse]("Mahasiswa berdasarkan NIK", nil),
+	"GET /api/misca/students/:id":         itemOperation[ListStudentsResponse]("Detail mahasiswa", nil),
+	"GET /api/misca/students/:id/history": itemOperation[StudentHistoryResponse]("Riwayat mahasiswa per semester", nil),
+
+	"GET /api/misca/lecturers":             tabular(listOperation[ListLecturerResponse]("List dosen", ListLecturerRequest{})),
+	"GET /api/misca/lecturers/total":       itemOperation[GetTotalLecturerResponse]("Total dosen", nil),
+	"GET /api/misca/lecturers/deletions":   listOperation[DeletedRecord]("Dosen yang terhapus", ListDeletionsRequest{}),
+	"GET /api/misca/lecturers/export":      exportOperation[ListLecturerResponse]("Export dosen (NDJSON)", ListLecturerRequest{}),
+	"GET /api/misca/lecturers/:id":         itemOperation[ListLecturerResponse]("Detail dosen", nil),
+	"GET /api/misca/lecturers/:id/classes": withLang(itemOperation[TeachingLoadResponse]("Beban mengajar dosen", TeachingLoadRequest{})),
+
+	"GET /api/misca/classes":                     tabular(listOperation[ListKelasResponse]("List kelas", ListStudentKelasRequest{})),
+	"GET /api/misca/classes/total":               itemOperation[GetTotalKelasResponse]("Total kelas", semesterQuery{}),
+	"GET /api/misca/classes/deletions":           listOperation[DeletedRecord]("Kelas yang terhapus", ListDeletionsRequest{}),
+	"GET /api/misca/classes/export":              withLang(exportOperation[ListKelasResponse]("Export kelas (NDJSON)", ListStudentKelasRequest{})),
+	"GET /api/misca/classes/:id":                 withLang(itemOperation[ListKelasResponse]("Detail kelas", nil)),
+	"GET /api/misca/classes/:id/students":        itemOperation[ClassRosterResponse]("Peserta kelas", ClassRosterRequest{}),
+	"GET /api/misca/classes/:id/grades":          itemOperation[ClassGradesResponse]("Nilai kelas", ClassGradesRequest{}),
+	"POST /api/misca/classes/:id/grades":         writeOperation[WriteClassGradesResponse]("Tulis nilai kelas", WriteClassGradesRequest{}),
+	"GET /api/misca/classes/:id/grading-scheme":  itemOperation[ClassGradingSchemeResponse]("Unsur nilai kelas", tipePenilaianQuery{}),
+	"GET /api/misca/classes/:id/grading-weights": itemOperation[GradingWeightsResponse]("Bobot nilai kelas", nil),
+	"PUT /api/misca/classes/:id/grading-weights": writeOperation[GradingWeightsResponse]("Ubah bobot nilai kelas", UpdateGradingWeightsRequest{}),
+
+	"GET /api/misca/student_classes":                tabular(listOperation[ListSimpleStudentKelas]("List kelas per mahasiswa", ListStudentKelasRequest{})),
+	"GET /api/misca/student_classes/total":          itemOperation[GetTotalKelasResponse]("Total mahasiswa yang mengambil kelas", semesterQuery{}),
+	"GET /api/misca/student_classes/deletions":      listOperation[DeletedRecord]("Kelas mahasiswa yang terhapus", ListDeletionsRequest{}),
+	"GET /api/misca/student_classes/export":         exportOperation[ListSimpleStudentKelas]("Export kelas per mahasiswa (NDJSON)", ListStudentKelasRequest{}),
+	"GET /api/misca/student_classes_details":        tabular(listOperation[ListStudentKelasResponse]("Detail kelas per mahasiswa", ListStudentKelasRequest{})),
+	"GET /api/misca/student_classes_details/total":  itemOperation[GetTotalKelasResponse]("Total detail kelas per mahasiswa", semesterQuery{}),
+	"GET /api/misca/student_classes_details/export": withLang(exportOperation[ListStudentKelasResponse]("Export detail kelas per mahasiswa (NDJSON)", ListStudentKelasRequest{})),
+
+	"GET /api/misca/rooms":              tabular(listOperation[RuanganResponse]("List ruangan", ListSyncRequest{})),
+	"GET /api/misca/rooms/total":        itemOperation[GetTotalStudentsResponse]("Total ruangan", nil),
+	"GET /api/misca/rooms/deletions":    listOperation[DeletedRecord]("Ruangan yang terhapus", ListDeletionsRequest{}),
+	"GET /api/misca/rooms/export":       exportOperation[RuanganResponse]("Export ruangan (NDJSON)", ListSyncRequest{}),
+	"GET /api/misca/rooms/availability": itemOperation[RoomAvailabilityResponse]("Ruangan kosong pada rentang waktu", RoomAvailabilityRequest{}),
+	"GET /api/misca/rooms/:id":          itemOperation[RuanganResponse]("Detail ruangan", nil),
+	"GET /api/misca/rooms/:id/schedule": withLang(itemOperation[RoomScheduleResponse]("Jadwal ruangan", RoomScheduleRequest{})),
+
+	"GET /api/misca/sms":           tabular(listOperation[*SMSTreeNode]("List program studi (tree, ?flat=true untuk list datar)", ListSMSRequest{})),
+	"GET /api/misca/sms/total":     itemOperation[GetTotalStudentsResponse]("Total program studi", nil),
+	"GET /api/misca/sms/deletions": listOperation[DeletedRecord]("Program studi yang terhapus", ListDeletionsRequest{}),
+	"GET /api/misca/sms/export":    exportOperation[SMS]("Export program studi (NDJSON)", ListSyncRequest{}),
+	"GET /api/misca/sms/:id":       itemOperation[SMS]("Detail program studi", nil),
+
+	"POST /api/misca/sessions/:id/attendance": func() apiOperation {
+		op := writeOperation[SubmitAttendanceResponse]("Presensi sesi perkuliahan", SubmitAttendanceRequest{})
+		op.idempotent = true
+		return op
+	}(),
+	"POST /api/misca/sessions/:id/start": writeOperation[SessionTransitionResponse]("Mulai sesi perkuliahan", nil),
+	"POST /api/misca/sessions/:id/end":   writeOperation[SessionTransitionResponse]("Akhiri sesi perkuliahan", nil),
+	"PUT /api/misca/sessions/:id/url":    writeOperation[SessionTransitionResponse]("Pasang URL sesi perkuliahan", AttachSessionURLRequest{}),
+
+	"GET /api/misca/grading-schemes":         listOperation[GradingSchemeResponse]("List unsur nilai", ListGradingSchemesRequest{}),
+	"GET /api/misca/grading-schemes/resolve": itemOperation[GradingSchemeResponse]("Unsur nilai yang berlaku untuk prodi", GradingSchemeRequest{}),
+	"GET /api/misca/schedule/conflicts":      withLang(itemOperation[ScheduleConflictsResponse]("Jadwal bentrok", ScheduleConflictsRequest{})),
+	"GET /api/misca/stats/students":          itemOperation[StatsResponse]("Statistik mahasiswa", StatsRequest{}),
+	"GET /api/misca/stats/classes":           itemOperation[StatsResponse]("Statistik kelas", StatsRequest{}),
+	"GET /api/misca/stats/overview":          itemOperation[StatsOverviewResponse]("Ringkasan statistik semester", StatsOverviewRequest{}),
+
+	"GET /api/v2/semesters":           withLang(listOperation[SemesterV2]("List semester", ListSemestersRequest{})),
+	"GET /api/v2/semesters/active":    withLang(itemOperation[SemesterV2]("Semester aktif", nil)),
+	"GET /api/v2/semesters/deletions": listOperation[DeletedRecord]("Semester yang terhapus", ListDeletionsRequest{}),
+	"GET /api/v2/semesters/:id":       withLang(itemOperation[SemesterV2]("Detail semester", nil)),
+
+	"GET /api/v2/students":           listOperation[PersonV2]("List mahasiswa", ListStudentsRequest{}),
+	"GET /api/v2/students/deletions": listOperation[DeletedRecord]("Mahasiswa yang terhapus", ListDeletionsRequest{}),
+	"GET /api/v2/students/:id":       itemOperation[PersonV2]("Detail mahasiswa", nil),
+
+	"GET /api/v2/lecturers":           listOperation[PersonV2]("List dosen", ListLecturerRequest{}),
+	"GET /api/v2/lecturers/deletions": listOperation[DeletedRecord]("Dosen yang terhapus", ListDeletionsRequest{}),
+	"GET /api/v2/lecturers/:id":       itemOperation[PersonV2]("Detail dosen", nil),
+
+	"GET /api/v2/classes":           withLang(listOperation[ClassV2]("List kelas", ListStudentKelasRequest{})),
+	"GET /api/v2/classes/deletions": listOperation[DeletedRecord]("Kelas yang terhapus", ListDeletionsRequest{}),
+	"GET /api/v2/classes/:id":       withLang(itemOperation[ClassV2]("Detail kelas", nil)),
+
+	"GET /api/v2/rooms":           listOperation[RoomV2]("List ruangan", ListSyncRequest{}),
+	"GET /api/v2/rooms/deletions": listOperation[DeletedRecord]("Ruangan yang terhapus", ListDeletionsRequest{}),
+	"GET /api/v2/rooms/:id":       itemOperation[RoomV2]("Detail ruangan", nil),
+
+	"GET /api/v2/study-programs":           listOperation[StudyProgramV2]("List program studi", pageQuery{}),
+	"GET /api/v2/study-programs/deletions": listOperation[DeletedRecord]("Program studi yang terhapus", ListDeletionsRequest{}),
+	"GET /api/v2/study-programs/:id":       itemOperation[StudyProgramV2]("Detail program studi", nil),
+
+	"POST /api/misca/calendar/tokens": func() apiOperation {
+		op := writeOperation[CalendarTokenResponse]("Buat token kalender", CreateCalendarTokenRequest{})
+		op.status = http.StatusCreated
+		return op
+	}(),
+	"DELETE /api/misca/calendar/tokens/:id": writeOperation[CalendarTokenResponse]("Cabut token kalender", nil),
+
+	"GET /api/calendar/students/:id.ics":  calendarFeedOperation("Feed iCalendar mahasiswa"),
+	"GET /api/calendar/lecturers/:id.ics": calendarFeedOperation("Feed iCalendar dosen"),
+	"GET /api/calendar/rooms/:id.ics":     calendarFeedOperation("Feed iCalendar ruangan"),
+	"GET /api/calendar/classes/:id.ics":   calendarFeedOperation("Feed iCalendar kelas"),
+
+	"GET " + openAPIDocPath:   {summary: "Dokumen OpenAPI 3", response: map[string]any{}},
+	"GET " + openAPIDocsPath:  {summary: "Dokumentasi interaktif", contentType: "text/html"},
+	"GET " + swaggerUICSSPath: {summary: "Stylesheet Swagger UI", contentType: "text/css"},
+	"GET " + swaggerUIJSPath:  {summary: "Script Swagger UI", contentType: "text/javascript"},
+}
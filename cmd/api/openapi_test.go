@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+// newDocsTestServer mendaftarkan route seperti main tanpa koneksi database.
+func newDocsTestServer() *ApplicationServer {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	app := NewApplicationServer(nil, logger, &gl.Config{AppName: "test"}, fiber.New())
+	app.SetupCommonMiddlewares()
+	app.SetupHealthCheckRoutes()
+	app.SetupRoutes()
+	app.SetupDocsRoutes()
+	return app
+}
+
+func TestOpenAPIOperationsMatchRoutes(t *testing.T) {
+	app := newDocsTestServer()
+
+	if _, err := app.BuildOpenAPIDocument(apiOperations); err != nil {
+		t.Fatalf("BuildOpenAPIDocument() error = %v", err)
+	}
+}
+
+func TestBuildOpenAPIDocumentMismatch(t *testing.T) {
+	app := newDocsTestServer()
+
+	operations := make(map[string]apiOperation, len(apiOperations))
+	for key, op := range apiOperations {
+		operations[key] = op
+	}
+	operations["GET /api/misca/unknown"] = apiOperation{summary: "tidak ada route"}
+
+	if _, err := app.BuildOpenAPIDocument(operations); !errors.Is(err, ErrSpecWithoutRoute) {
+		t.Errorf("entri tanpa route: error = %v, want %v", err, ErrSpecWithoutRoute)
+	}
+
+	app.router.Get("/api/misca/undocumented", func(c *fiber.Ctx) error { return nil })
+
+	if _, err := app.BuildOpenAPIDocument(apiOperations); !errors.Is(err, ErrRouteWithoutSpec) {
+		t.Errorf("route tanpa entri: error = %v, want %v", err, ErrRouteWithoutSpec)
+	}
+}
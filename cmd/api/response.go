@@ -2,9 +2,11 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 	gl "lab.garudacyber.co.id/g-learning-connector"
 	"net/http"
 
@@ -24,6 +26,7 @@ type ApiResponse[T any] struct {
 type ListDataApiResponseWrapper[T any] struct {
 	List     []T          `json:"list"`
 	PageInfo *gl.PageInfo `json:"page_info,omitempty"`
+	Sync     *gl.SyncInfo `json:"sync,omitempty"`
 }
 
 type ErrorHandler interface {
@@ -34,6 +37,9 @@ type ErrorHandler interface {
 
 var ErrorTranslator ut.Translator
 
+// Validator dipakai untuk memvalidasi body request, errornya ditangani oleh HandleError.
+var Validator = validator.New(validator.WithRequiredStructEnabled())
+
 type ValidationErrorMessage struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
@@ -101,3 +107,59 @@ func HandleError(c *fiber.Ctx, err error) error {
 		Errors:  &errStr,
 	})
 }
+
+// findOne menjalankan query untuk satu baris data. Jika tidak ada baris yang cocok,
+// mengembalikan NotFoundError dengan pesan notFound supaya HandleError merespon 404.
+func findOne[T any](q *gorm.DB, notFound string) (T, error) {
+	var item T
+
+	result := q.Limit(1).Scan(&item)
+	if result.Error != nil {
+		return item, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return item, NewNotFoundError(notFound)
+	}
+
+	return item, nil
+}
+
+// maxDuplicateCandidates batas jumlah id kandidat yang disebutkan pada response 409 findUnique.
+const maxDuplicateCandidates = 10
+
+var ErrDuplicateMatch = errors.New("lebih dari satu data cocok")
+
+// findUnique seperti findOne untuk pencarian berdasarkan kolom yang seharusnya unik tetapi tidak dijamin
+// oleh database (misalnya nik). Jika lebih dari satu baris cocok, mengembalikan 409 yang menyebutkan id
+// kandidat (paling banyak maxDuplicateCandidates) alih-alih memilih salah satu secara diam-diam.
+func findUnique[T any](q *gorm.DB, notFound string, id func(T) string) (T, error) {
+	var (
+		item  T
+		items []T
+		total int64
+	)
+
+	if err := q.Session(&gorm.Session{}).Limit(maxDuplicateCandidates).Scan(&items).Error; err != nil {
+		return item, err
+	}
+
+	switch len(items) {
+	case 0:
+		return item, NewNotFoundError(notFound)
+	case 1:
+		return items[0], nil
+	}
+
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return item, err
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, candidate := range items {
+		ids = append(ids, id(candidate))
+	}
+
+	info := fmt.Sprintf("Ditemukan %d data yang cocok, id kandidat: %s", total, strings.Join(ids, ", "))
+	return item, NewAppError(http.StatusConflict, info, ErrDuplicateMatch)
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	roomBookingSourceJadwal  = "jadwal"
+	roomBookingSourceSession = "jadwal_perkuliahan"
+
+	// rentang default sesi pada /rooms/:id/schedule, mulai hari ini
+	defaultRoomScheduleDays = 7
+)
+
+type (
+	RoomAvailabilityRequest struct {
+		Date        string `json:"date" form:"date" query:"date" validate:"required"`
+		From        string `json:"from" form:"from" query:"from" validate:"required"`
+		To          string `json:"to" form:"to" query:"to" validate:"required"`
+		MinCapacity int    `json:"min_capacity" form:"min_capacity" query:"min_capacity" validate:"min=0"`
+		IDSMS       string `json:"id_sms" form:"id_sms" query:"id_sms"`
+	}
+
+	RoomAvailabilityResponse struct {
+		Tanggal  string            `json:"tanggal"`
+		Hari     int               `json:"hari"`
+		NamaHari string            `json:"nama_hari"`
+		Dari     string            `json:"dari"`
+		Sampai   string            `json:"sampai"`
+		Semester string            `json:"semester"`
+		Tersedia []RuanganResponse `json:"tersedia"`
+		Terpakai []RoomOccupancy   `json:"terpakai"`
+	}
+
+	RoomOccupancy struct {
+		RuanganResponse
+		Pemakaian []RoomBooking `json:"pemakaian"`
+	}
+
+	RoomScheduleRequest struct {
+		Semester  string `json:"semester" form:"semester" query:"semester"`
+		StartDate string `json:"start_date" form:"start_date" query:"start_date"`
+		EndDate   string `json:"end_date" form:"end_date" query:"end_date"`
+	}
+
+	RoomScheduleResponse struct {
+		Ruangan  RuanganResponse `json:"ruangan"`
+		Semester string          `json:"semester"`
+		// jadwal mingguan kelas pada semester
+		Jadwal []RoomBooking `json:"jadwal"`
+		// sesi jadwal_perkuliahan antara start_date dan end_date
+		Sesi []RoomBooking `json:"sesi"`
+	}
+
+	// RoomBooking adalah pemakaian ruangan oleh jadwal mingguan atau oleh satu sesi jadwal_perkuliahan.
+	RoomBooking struct {
+		Sumber              string  `json:"sumber" gorm:"-"`
+		IDRuangan           string  `json:"id_ruangan" gorm:"column:id_ruangan"`
+		IDKelas             string  `json:"id_kelas" gorm:"column:id_kelas"`
+		IDJadwalPerkuliahan *int64  `json:"id_jadwal_perkuliahan,omitempty" gorm:"column:id_jadwal_perkuliahan"`
+		Hari                int     `json:"hari" gorm:"column:hari"`
+		NamaHari            string  `json:"nama_hari" gorm:"-"`
+		Tanggal             *string `json:"tanggal,omitempty" gorm:"column:tanggal"`
+		JamMulai            string  `json:"jam_mulai" gorm:"column:jam_mulai"`
+		JamSelesai          string  `json:"jam_selesai" gorm:"column:jam_selesai"`
+	}
+
+	// roomBookingFilter: field kosong berarti tidak difilter.
+	roomBookingFilter struct {
+		Semester  string
+		IDRuangan string
+		Hari      *int
+		DateFrom  string
+		DateTo    string
+		From      string
+		To        string
+		// jadwal mingguan dilewati, misalnya jika tanggal berada di luar semester manapun
+		SkipWeekly bool
+	}
+
+	// roomScheduleSource membedakan tabel ruangan dan kelas pada skema Misca dan Smart.
+	roomScheduleSource struct {
+		rooms          func() *gorm.DB
+		convert        func([]Ruangan) ([]RuanganResponse, error)
+		activeSemester func() (string, error)
+		kelasTable     string
+		// jadwal_perkuliahan hanya tersedia pada Misca
+		sessions bool
+		// kolom kapasitas hanya tersedia pada Misca
+		capacity bool
+	}
+)
+
+var (
+	ErrInvalidTimeRange      = errors.New("jam from harus lebih awal dari jam to")
+	ErrInvalidDateRange      = errors.New("start_date harus sebelum atau sama dengan end_date")
+	ErrRoomCapacityNotStored = errors.New("kapasitas ruangan tidak tersedia pada instansi ini, min_capacity tidak bisa dipakai")
+)
+
+func (a *ApplicationServer) roomScheduleSourceMisca() roomScheduleSource {
+	return roomScheduleSource{
+		rooms:          func() *gorm.DB { return a.db.Table("ruangan") },
+		convert:        convertRuanganMisca,
+		activeSemester: a.getActiveSemesterIDMisca,
+		kelasTable:     "kelaskuliah",
+		sessions:       true,
+		capacity:       true,
+	}
+}
+
+func (a *ApplicationServer) roomScheduleSourceSmart() roomScheduleSource {
+	return roomScheduleSource{
+		rooms:          a.roomsQuerySmart,
+		convert:        convertRuanganSmart,
+		activeSemester: a.getActiveSemesterIDSmart,
+		kelasTable:     "kelas_kuliah",
+	}
+}
+
+// GetRoomAvailabilityMisca mengembalikan ruangan yang kosong pada tanggal dan jam tertentu. Ruangan dianggap terpakai
+// jika ada jadwal mingguan kelas pada semester yang periodenya memuat tanggal tersebut di hari yang sama, atau sesi
+// jadwal_perkuliahan (selain online) pada tanggal tersebut, yang jamnya beririsan dengan from-to. Jika tanggal
+// berada di luar semester manapun, jadwal mingguan tidak dihitung.
+func (a *ApplicationServer) GetRoomAvailabilityMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetRoomAvailabilitySmart(c)
+	}
+
+	return a.getRoomAvailability(c, a.roomScheduleSourceMisca())
+}
+
+func (a *ApplicationServer) GetRoomAvailabilitySmart(c *fiber.Ctx) error {
+	return a.getRoomAvailability(c, a.roomScheduleSourceSmart())
+}
+
+func (a *ApplicationServer) getRoomAvailability(c *fiber.Ctx, src roomScheduleSource) error {
+	req := new(RoomAvailabilityRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	date, err := time.ParseInLocation(time.DateOnly, req.Date, time.Local)
+	if err != nil {
+		return HandleError(c, NewBadRequestError(ErrInvalidTanggal))
+	}
+
+	from, err := gl.NormalizeClock(req.From)
+	if err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	to, err := gl.NormalizeClock(req.To)
+	if err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	if from >= to {
+		return HandleError(c, NewBadRequestError(ErrInvalidTimeRange))
+	}
+
+	if req.MinCapacity > 0 && !src.capacity {
+		return HandleError(c, NewBadRequestError(ErrRoomCapacityNotStored))
+	}
+
+	semester, err := a.findSemesterByDate(date)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	roomsQuery := src.rooms()
+	if req.MinCapacity > 0 {
+		roomsQuery = roomsQuery.Where("kapasitas >= ?", req.MinCapacity)
+	}
+
+	models := make([]Ruangan, 0)
+	if err := roomsQuery.Order("id_ruangan ASC").Find(&models).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	rooms, err := src.convert(models)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	hari := int(date.Weekday())
+	bookings, err := a.findRoomBookings(src, roomBookingFilter{
+		Semester:   semester,
+		SkipWeekly: semester == "",
+		Hari:       &hari,
+		DateFrom:   req.Date,
+		DateTo:     req.Date,
+		From:       from,
+		To:         to,
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	bookingsByRoom := make(map[string][]RoomBooking)
+	for _, booking := range bookings {
+		bookingsByRoom[booking.IDRuangan] = append(bookingsByRoom[booking.IDRuangan], booking)
+	}
+
+	response := RoomAvailabilityResponse{
+		Tanggal:  req.Date,
+		Hari:     hari,
+		NamaHari: gl.NamaHari(hari),
+		Dari:     from,
+		Sampai:   to,
+		Semester: semester,
+		Tersedia: make([]RuanganResponse, 0),
+		Terpakai: make([]RoomOccupancy, 0),
+	}
+
+	for _, room := range rooms {
+		// ruangan tanpa id_sms dianggap bisa dipakai oleh semua prodi
+		if req.IDSMS != "" && len(room.IDSMS) > 0 && !slices.Contains(room.IDSMS, req.IDSMS) {
+			continue
+		}
+
+		if used, ok := bookingsByRoom[room.IDRuangan]; ok {
+			response.Terpakai = append(response.Terpakai, RoomOccupancy{RuanganResponse: room, Pemakaian: used})
+			continue
+		}
+
+		response.Tersedia = append(response.Tersedia, room)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[RoomAvailabilityResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan ketersediaan ruangan",
+		Data:    response,
+	})
+}
+
+func (a *ApplicationServer) GetRoomScheduleMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetRoomScheduleSmart(c)
+	}
+
+	return a.getRoomSchedule(c, a.roomScheduleSourceMisca())
+}
+
+func (a *ApplicationServer) GetRoomScheduleSmart(c *fiber.Ctx) error {
+	return a.getRoomSchedule(c, a.roomScheduleSourceSmart())
+}
+
+// getRoomSchedule mengembalikan pemakaian satu ruangan: jadwal mingguan pada semester (default semester aktif)
+// dan sesi jadwal_perkuliahan antara start_date dan end_date (default 7 hari mulai hari ini).
+func (a *ApplicationServer) getRoomSchedule(c *fiber.Ctx, src roomScheduleSource) error {
+	req := new(RoomScheduleRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	today := time.Now().Format(time.DateOnly)
+	if req.StartDate == "" {
+		req.StartDate = today
+	}
+
+	startDate, err := time.ParseInLocation(time.DateOnly, req.StartDate, time.Local)
+	if err != nil {
+		return HandleError(c, NewBadRequestError(ErrInvalidTanggal))
+	}
+
+	if req.EndDate == "" {
+		req.EndDate = startDate.AddDate(0, 0, defaultRoomScheduleDays-1).Format(time.DateOnly)
+	}
+
+	endDate, err := time.ParseInLocation(time.DateOnly, req.EndDate, time.Local)
+	if err != nil {
+		return HandleError(c, NewBadRequestError(ErrInvalidTanggal))
+	}
+
+	if endDate.Before(startDate) {
+		return HandleError(c, NewBadRequestError(ErrInvalidDateRange))
+	}
+
+	room, err := findOne[Ruangan](src.rooms().Where("id_ruangan = ?", c.Params("id")), "Ruangan tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	rooms, err := src.convert([]Ruangan{room})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Semester == "" {
+		req.Semester, err = src.activeSemester()
+		if err != nil {
+			return HandleError(c, err)
+		}
+	}
+
+	bookings, err := a.findRoomBookings(src, roomBookingFilter{
+		Semester:  req.Semester,
+		IDRuangan: room.IDRuangan,
+		DateFrom:  req.StartDate,
+		DateTo:    req.EndDate,
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	response := RoomScheduleResponse{
+		Ruangan:  rooms[0],
+		Semester: req.Semester,
+		Jadwal:   make([]RoomBooking, 0),
+		Sesi:     make([]RoomBooking, 0),
+	}
+
+	for _, booking := range bookings {
+		if booking.Sumber == roomBookingSourceSession {
+			response.Sesi = append(response.Sesi, booking)
+		} else {
+			response.Jadwal = append(response.Jadwal, booking)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[RoomScheduleResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan jadwal ruangan",
+		Data:    response,
+	})
+}
+
+// findRoomBookings mengambil pemakaian ruangan dari jadwal mingguan kelas pada semester dan, untuk Misca,
+// dari sesi jadwal_perkuliahan antara DateFrom dan DateTo. Sesi online tidak memakai ruangan.
+func (a *ApplicationServer) findRoomBookings(src roomScheduleSource, filter roomBookingFilter) ([]RoomBooking, error) {
+	weekly := make([]RoomBooking, 0)
+	q := a.db.Table("jadwal").
+		Select(`
+			CAST(jadwal.id_ruangan AS CHAR) AS id_ruangan,
+			CAST(jadwal.id_kls AS CHAR) AS id_kelas,
+			jadwal.hari AS hari,
+			jadwal.jam_mulai AS jam_mulai,
+			jadwal.jam_selesai AS jam_selesai
+		`).
+		Joins("JOIN "+src.kelasTable+" ON "+src.kelasTable+".id_kls = jadwal.id_kls").
+		Where(src.kelasTable+".id_smt = ?", filter.Semester).
+		Where("jadwal.id_ruangan IS NOT NULL")
+
+	if filter.IDRuangan != "" {
+		q = q.Where("jadwal.id_ruangan = ?", filter.IDRuangan)
+	}
+	if filter.Hari != nil {
+		q = q.Where("jadwal.hari = ?", *filter.Hari)
+	}
+	if filter.From != "" && filter.To != "" {
+		q = q.Where("jadwal.jam_mulai < ? AND jadwal.jam_selesai > ?", filter.To, filter.From)
+	}
+
+	if !filter.SkipWeekly {
+		if err := q.Order("jadwal.hari ASC, jadwal.jam_mulai ASC").Scan(&weekly).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range weekly {
+		weekly[i].Sumber = roomBookingSourceJadwal
+		weekly[i].NamaHari = gl.NamaHari(weekly[i].Hari)
+	}
+
+	if !src.sessions {
+		return weekly, nil
+	}
+
+	sessions := make([]RoomBooking, 0)
+	q = a.db.Table("jadwal_perkuliahan").
+		Select(`
+			CAST(jadwal_perkuliahan.id_ruangan AS CHAR) AS id_ruangan,
+			CAST(jadwal_perkuliahan.id_kls AS CHAR) AS id_kelas,
+			jadwal_perkuliahan.id AS id_jadwal_perkuliahan,
+			DAYOFWEEK(jadwal_perkuliahan.tanggal) - 1 AS hari,
+			DATE_FORMAT(jadwal_perkuliahan.tanggal, '%Y-%m-%d') AS tanggal,
+			jadwal_perkuliahan.jam_mulai AS jam_mulai,
+			jadwal_perkuliahan.jam_selesai AS jam_selesai
+		`).
+		Where("jadwal_perkuliahan.id_ruangan IS NOT NULL").
+		Where("jadwal_perkuliahan.metode_pembelajaran != ?", "online").
+		Where("jadwal_perkuliahan.tanggal BETWEEN ? AND ?", filter.DateFrom, filter.DateTo)
+
+	if filter.IDRuangan != "" {
+		q = q.Where("jadwal_perkuliahan.id_ruangan = ?", filter.IDRuangan)
+	}
+	if filter.From != "" && filter.To != "" {
+		q = q.Where("jadwal_perkuliahan.jam_mulai < ? AND jadwal_perkuliahan.jam_selesai > ?", filter.To, filter.From)
+	}
+
+	if err := q.Order("jadwal_perkuliahan.tanggal ASC, jadwal_perkuliahan.jam_mulai ASC").Scan(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Sumber = roomBookingSourceSession
+		sessions[i].NamaHari = gl.NamaHari(sessions[i].Hari)
+	}
+
+	return append(weekly, sessions...), nil
+}
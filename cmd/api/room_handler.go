@@ -2,9 +2,13 @@ package main
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
 )
 
 func (a *ApplicationServer) ListRoomsMisca(c *fiber.Ctx) error {
@@ -12,27 +16,28 @@ func (a *ApplicationServer) ListRoomsMisca(c *fiber.Ctx) error {
 		return a.ListRoomsSmart(c)
 	}
 
+	req := new(ListSyncRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listRoomsQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
 	rooms := make([]Ruangan, 0)
-	if err := a.db.Table("ruangan").Find(&rooms).Error; err != nil {
+	if err := q.Find(&rooms).Error; err != nil {
 		return HandleError(c, err)
 	}
 
-	response := make([]RuanganResponse, 0)
-	for _, r := range rooms {
-		var idsms []string
-		json.Unmarshal([]byte(r.IDSMSRaw), &idsms) // parsing string JSON ke slice
+	response, err := convertRuanganMisca(rooms)
+	if err != nil {
+		return HandleError(c, err)
+	}
 
-		response = append(response, RuanganResponse{
-			IDRuangan:      r.IDRuangan,
-			IDSMS:          idsms,
-			NamaRuangan:    r.NamaRuangan,
-			IDJenisRuangan: r.IDJenisRuangan,
-			KodeRuangan:    r.KodeRuangan,
-			Keterangan:     r.Keterangan,
-			Kapasitas:      r.Kapasitas,
-			CreatedAt:      r.CreatedAt,
-			UpdatedAt:      r.UpdatedAt,
-		})
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, roomsTable, response, nil)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[RuanganResponse]]{
@@ -42,6 +47,7 @@ func (a *ApplicationServer) ListRoomsMisca(c *fiber.Ctx) error {
 		Message: "Sukses mendapatkan data ruangan",
 		Data: ListDataApiResponseWrapper[RuanganResponse]{
 			List: response,
+			Sync: syncInfo,
 		},
 	})
 }
@@ -69,39 +75,28 @@ func (a *ApplicationServer) GetTotalRoomsMisca(c *fiber.Ctx) error {
 }
 
 func (a *ApplicationServer) ListRoomsSmart(c *fiber.Ctx) error {
+	req := new(ListSyncRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listRoomsQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
 	rooms := make([]Ruangan, 0)
-	if err := a.db.
-		Select("id_ruangan AS id_ruangan, id_sms AS id_sms, kode_ruangan AS kode_ruangan, kode_ruangan AS nama_ruangan, ket AS keterangan").
-		Table("ruangan").Find(&rooms).Error; err != nil {
+	if err := q.Find(&rooms).Error; err != nil {
 		return HandleError(c, err)
 	}
 
-	response := make([]RuanganResponse, 0)
-	for _, r := range rooms {
-		// cek jika r.IDSMSRaw ini tidak array -> ["86205","86206","87203","88201"]
-		// maka jangan di unmarshal, masukkan langsung value r.IDSMSRaw ke slice idsms
-		var idsms []string
-		err := json.Unmarshal([]byte(r.IDSMSRaw), &idsms)
-		if err != nil {
-			// Jika gagal unmarshal DAN string-nya tidak kosong,
-			// anggap sebagai ID tunggal dan masukkan ke slice.
-			if r.IDSMSRaw != "" {
-				idsms = []string{r.IDSMSRaw}
-			}
-			// Jika string kosong, idsms akan tetap menjadi slice kosong, yang sudah benar.
-		}
+	response, err := convertRuanganSmart(rooms)
+	if err != nil {
+		return HandleError(c, err)
+	}
 
-		response = append(response, RuanganResponse{
-			IDRuangan:      r.IDRuangan,
-			IDSMS:          idsms,
-			NamaRuangan:    r.NamaRuangan,
-			IDJenisRuangan: r.IDJenisRuangan,
-			KodeRuangan:    r.KodeRuangan,
-			Keterangan:     r.Keterangan,
-			Kapasitas:      r.Kapasitas,
-			CreatedAt:      r.CreatedAt,
-			UpdatedAt:      r.UpdatedAt,
-		})
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, roomsTable, response, nil)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[RuanganResponse]]{
@@ -111,6 +106,7 @@ func (a *ApplicationServer) ListRoomsSmart(c *fiber.Ctx) error {
 		Message: "Sukses mendapatkan data ruangan",
 		Data: ListDataApiResponseWrapper[RuanganResponse]{
 			List: response,
+			Sync: syncInfo,
 		},
 	})
 }
@@ -132,3 +128,173 @@ func (a *ApplicationServer) GetTotalRoomsSmart(c *fiber.Ctx) error {
 		},
 	})
 }
+
+func (a *ApplicationServer) GetRoomMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetRoomSmart(c)
+	}
+
+	room, err := findOne[Ruangan](a.db.Table("ruangan").Where("id_ruangan = ?", c.Params("id")), "Ruangan tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	response, err := convertRuanganMisca([]Ruangan{room})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[RuanganResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data ruangan",
+		Data:    response[0],
+	})
+}
+
+func (a *ApplicationServer) GetRoomSmart(c *fiber.Ctx) error {
+	room, err := findOne[Ruangan](a.roomsQuerySmart().Where("id_ruangan = ?", c.Params("id")), "Ruangan tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	response, err := convertRuanganSmart([]Ruangan{room})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[RuanganResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data ruangan",
+		Data:    response[0],
+	})
+}
+
+// listRoomsQueryMisca membangun query list ruangan (sync) tanpa paginasi
+func (a *ApplicationServer) listRoomsQueryMisca(req *ListSyncRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	return a.ApplySyncFilter(a.db.Table("ruangan"), req.SyncFilter, roomsSyncConditionsMisca)
+}
+
+// listRoomsQuerySmart membangun query list ruangan (sync) tanpa paginasi
+func (a *ApplicationServer) listRoomsQuerySmart(req *ListSyncRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	// tabel ruangan Smart tidak memiliki timestamp, selalu snapshot penuh
+	return a.ApplySyncFilter(a.roomsQuerySmart(), req.SyncFilter, nil)
+}
+
+// roomsQuerySmart adalah query dasar ruangan Smart yang dipakai oleh list dan detail
+func (a *ApplicationServer) roomsQuerySmart() *gorm.DB {
+	return a.db.
+		Table("ruangan").
+		Select("id_ruangan AS id_ruangan, id_sms AS id_sms, kode_ruangan AS kode_ruangan, kode_ruangan AS nama_ruangan, ket AS keterangan")
+}
+
+func convertRuanganMisca(rooms []Ruangan) ([]RuanganResponse, error) {
+	response := make([]RuanganResponse, 0, len(rooms))
+	for _, r := range rooms {
+		var idsms []string
+		json.Unmarshal([]byte(r.IDSMSRaw), &idsms) // parsing string JSON ke slice
+
+		response = append(response, newRuanganResponse(r, idsms))
+	}
+
+	return response, nil
+}
+
+func convertRuanganSmart(rooms []Ruangan) ([]RuanganResponse, error) {
+	response := make([]RuanganResponse, 0, len(rooms))
+	for _, r := range rooms {
+		// cek jika r.IDSMSRaw ini tidak array -> ["86205","86206","87203","88201"]
+		// maka jangan di unmarshal, masukkan langsung value r.IDSMSRaw ke slice idsms
+		var idsms []string
+		err := json.Unmarshal([]byte(r.IDSMSRaw), &idsms)
+		if err != nil {
+			// Jika gagal unmarshal DAN string-nya tidak kosong,
+			// anggap sebagai ID tunggal dan masukkan ke slice.
+			if r.IDSMSRaw != "" {
+				idsms = []string{r.IDSMSRaw}
+			}
+			// Jika string kosong, idsms akan tetap menjadi slice kosong, yang sudah benar.
+		}
+
+		response = append(response, newRuanganResponse(r, idsms))
+	}
+
+	return response, nil
+}
+
+func newRuanganResponse(r Ruangan, idsms []string) RuanganResponse {
+	return RuanganResponse{
+		IDRuangan:      r.IDRuangan,
+		IDSMS:          idsms,
+		NamaRuangan:    r.NamaRuangan,
+		IDJenisRuangan: r.IDJenisRuangan,
+		KodeRuangan:    r.KodeRuangan,
+		Keterangan:     r.Keterangan,
+		Kapasitas:      r.Kapasitas,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+}
+
+func (a *ApplicationServer) ExportRoomsMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ExportRoomsSmart(c)
+	}
+
+	req := new(ListSyncRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listRoomsQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, convertRuanganMisca)
+}
+
+func (a *ApplicationServer) ExportRoomsSmart(c *fiber.Ctx) error {
+	req := new(ListSyncRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listRoomsQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, convertRuanganSmart)
+}
+
+var roomsTable = TabularTable[RuanganResponse]{
+	Name: "ruangan",
+	Columns: []TabularColumn{
+		{HeaderID: "ID Ruangan", HeaderEN: "Room ID"},
+		{HeaderID: "ID Prodi", HeaderEN: "Study Program IDs"},
+		{HeaderID: "Nama Ruangan", HeaderEN: "Room Name"},
+		{HeaderID: "ID Jenis Ruangan", HeaderEN: "Room Type ID"},
+		{HeaderID: "Kode Ruangan", HeaderEN: "Room Code"},
+		{HeaderID: "Keterangan", HeaderEN: "Description"},
+		{HeaderID: "Kapasitas", HeaderEN: "Capacity"},
+		{HeaderID: "Dibuat", HeaderEN: "Created At"},
+		{HeaderID: "Diperbarui", HeaderEN: "Updated At"},
+	},
+	Rows: func(r RuanganResponse) [][]string {
+		return [][]string{{
+			r.IDRuangan,
+			strings.Join(r.IDSMS, ","),
+			r.NamaRuangan,
+			r.IDJenisRuangan,
+			r.KodeRuangan,
+			r.Keterangan,
+			strconv.Itoa(r.Kapasitas),
+			tabularTime(&r.CreatedAt),
+			tabularTime(&r.UpdatedAt),
+		}}
+	},
+}
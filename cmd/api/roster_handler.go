@@ -0,0 +1,192 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+type (
+	ClassRosterRequest struct {
+		gl.Filter
+	}
+
+	// ClassRosterResponse berisi identitas kelas dan dosen pengajar sebagai header,
+	// diikuti daftar mahasiswa peserta kelas dengan paginasi.
+	ClassRosterResponse struct {
+		KelasInfo
+		Dosen    []ClassLecturer      `json:"dosen"`
+		List     []ClassRosterStudent `json:"list"`
+		PageInfo *gl.PageInfo         `json:"page_info,omitempty"`
+	}
+
+	ClassLecturer struct {
+		IDPTK            string  `json:"id_ptk" gorm:"column:id_ptk"`
+		Nama             *string `json:"nama" gorm:"column:nama"`
+		RencanaPertemuan *int    `json:"rencana_pertemuan" gorm:"column:rencana_pertemuan"`
+	}
+
+	ClassRosterStudent struct {
+		IDPesertaDidik string  `json:"id_pd" gorm:"column:id_pd"`
+		IDMahasiswa    string  `json:"id_mahasiswa" gorm:"column:id_mahasiswa"`
+		NIK            string  `json:"nik" gorm:"column:nik"`
+		Name           string  `json:"name" gorm:"column:nama"`
+		IDSMS          *string `json:"id_sms" gorm:"column:id_sms"`
+		Prodi          *string `json:"prodi" gorm:"column:prodi"`
+	}
+)
+
+func NewClassRosterRequest() *ClassRosterRequest {
+	return &ClassRosterRequest{
+		Filter: gl.NewFilterPagination(),
+	}
+}
+
+// FindClassLecturersMisca mengambil dosen pengajar kelas dari akt_mengajar_dosen.
+func (a *ApplicationServer) FindClassLecturersMisca(idKelas string) ([]ClassLecturer, error) {
+	lecturers := make([]ClassLecturer, 0)
+	err := a.db.Table("akt_mengajar_dosen").
+		Select(`
+			CAST(akt_mengajar_dosen.id_ptk AS CHAR) AS id_ptk,
+			dosen.nama_dosen AS nama,
+			akt_mengajar_dosen.temu_rencana AS rencana_pertemuan
+		`).
+		Joins("LEFT JOIN dosen ON dosen.id_ptk = akt_mengajar_dosen.id_ptk").
+		Where("akt_mengajar_dosen.id_kls = ?", idKelas).
+		Order("akt_mengajar_dosen.id_ptk ASC").
+		Scan(&lecturers).Error
+
+	return lecturers, err
+}
+
+// FindClassLecturersSmart mengambil dosen pengajar kelas dari akt_ajar_dosen,
+// id_reg_ptk dikembalikan sebagai id_ptk sama seperti id_dosen_pengajar pada list kelas.
+func (a *ApplicationServer) FindClassLecturersSmart(idKelas string) ([]ClassLecturer, error) {
+	lecturers := make([]ClassLecturer, 0)
+	err := a.db.Table("akt_ajar_dosen").
+		Select(`
+			CAST(akt_ajar_dosen.id_reg_ptk AS CHAR) AS id_ptk,
+			dosen.nm_ptk AS nama,
+			akt_ajar_dosen.jml_tm_renc AS rencana_pertemuan
+		`).
+		Joins("LEFT JOIN dosen ON dosen.id_ptk = akt_ajar_dosen.id_reg_ptk").
+		Where("akt_ajar_dosen.id_kls = ?", idKelas).
+		Order("akt_ajar_dosen.id_reg_ptk ASC").
+		Scan(&lecturers).Error
+
+	return lecturers, err
+}
+
+func (a *ApplicationServer) ListClassStudentsMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ListClassStudentsSmart(c)
+	}
+
+	kelas, err := a.FindKelasMisca(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	lecturers, err := a.FindClassLecturersMisca(kelas.IDKelas)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	q := a.db.Table("nilai").
+		Select(`
+			nilai.id_pd AS id_pd,
+			mahasiswa.id AS id_mahasiswa,
+			mahasiswa.nik AS nik,
+			mahasiswa.nama_mahasiswa AS nama,
+			mahasiswa_histori.id_sms AS id_sms,
+			sms.nm_lemb AS prodi
+		`).
+		Joins("JOIN mahasiswa_histori ON mahasiswa_histori.id_pd = nilai.id_pd").
+		Joins("JOIN mahasiswa ON mahasiswa.id = mahasiswa_histori.id_mahasiswa").
+		Joins("LEFT JOIN sms ON sms.id_sms = mahasiswa_histori.id_sms").
+		Where("nilai.id_kls = ?", kelas.IDKelas)
+
+	return a.listClassStudents(c, kelas, lecturers, q, "mahasiswa.nama_mahasiswa")
+}
+
+func (a *ApplicationServer) ListClassStudentsSmart(c *fiber.Ctx) error {
+	kelas, err := a.FindKelasSmart(c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	lecturers, err := a.FindClassLecturersSmart(kelas.IDKelas)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	q := a.db.Table("nilai").
+		Select(`
+			nilai.id_reg_pd AS id_pd,
+			nilai.id_reg_pd AS id_mahasiswa,
+			mahasiswa.nik AS nik,
+			mahasiswa.nm_pd AS nama,
+			mahasiswa.id_sms AS id_sms,
+			sms.nm_lemb AS prodi
+		`).
+		Joins("JOIN mahasiswa ON mahasiswa.id_pd = nilai.id_reg_pd").
+		Joins("LEFT JOIN sms ON sms.id_sms = mahasiswa.id_sms").
+		Where("nilai.id_kls = ?", kelas.IDKelas)
+
+	return a.listClassStudents(c, kelas, lecturers, q, "mahasiswa.nm_pd")
+}
+
+// listClassStudents menambahkan keyword (nama atau nik), sorting dan paginasi pada query peserta kelas.
+func (a *ApplicationServer) listClassStudents(c *fiber.Ctx, kelas *KelasInfo, lecturers []ClassLecturer, q *gorm.DB, nameColumn string) error {
+	req := NewClassRosterRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Filter.HasKeyword() {
+		q = q.Where("("+nameColumn+" LIKE ? OR mahasiswa.nik LIKE ?)", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	}
+
+	offset := req.Filter.GetOffset()
+	limit := req.Filter.GetLimit()
+
+	var totalData int64
+	if err := q.Count(&totalData).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Filter.HasSort() {
+		q = q.Order(clause.OrderByColumn{
+			Column: clause.Column{Name: req.Filter.SortBy},
+			Desc:   req.Filter.IsDesc(),
+		})
+	} else {
+		q = q.Order(nameColumn + " ASC")
+	}
+
+	students := make([]ClassRosterStudent, 0)
+	if err := q.Offset(int(offset)).Limit(int(limit)).Scan(&students).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ClassRosterResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data peserta kelas",
+		Data: ClassRosterResponse{
+			KelasInfo: *kelas,
+			Dosen:     lecturers,
+			List:      students,
+			PageInfo:  pageInfo,
+		},
+	})
+}
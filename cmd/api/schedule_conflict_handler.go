@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	conflictTypeDosen     = "dosen"
+	conflictTypeRuangan   = "ruangan"
+	conflictTypeMahasiswa = "mahasiswa"
+
+	// maxScheduleConflictRows batas baris hasil self join per jenis dan sumber, konflik mahasiswa bisa menghasilkan
+	// satu baris untuk setiap mahasiswa pada setiap pasangan kelas yang bentrok
+	maxScheduleConflictRows = 5000
+)
+
+type (
+	ScheduleConflictsRequest struct {
+		Semester string `json:"semester" form:"semester" query:"semester"`
+		// kosong berarti semua jenis konflik
+		Jenis string `json:"jenis" form:"jenis" query:"jenis" validate:"omitempty,oneof=dosen ruangan mahasiswa"`
+	}
+
+	ScheduleConflictsResponse struct {
+		Semester string                `json:"semester"`
+		Total    ScheduleConflictTotal `json:"total"`
+		// true jika hasil salah satu jenis dan sumber melebihi maxScheduleConflictRows, total menjadi batas bawah
+		Terpotong bool               `json:"terpotong"`
+		Konflik   []ScheduleConflict `json:"konflik"`
+	}
+
+	ScheduleConflictTotal struct {
+		Dosen     int `json:"dosen"`
+		Ruangan   int `json:"ruangan"`
+		Mahasiswa int `json:"mahasiswa"`
+	}
+
+	// ScheduleConflict adalah dua kelas yang jamnya beririsan pada hari (jadwal) atau tanggal (jadwal_perkuliahan)
+	// yang sama dan memakai dosen, ruangan, atau mahasiswa yang sama. Subjek berisi id_ptk, id_ruangan, atau id_pd.
+	ScheduleConflict struct {
+		Jenis    string         `json:"jenis"`
+		Sumber   string         `json:"sumber"`
+		Subjek   []string       `json:"subjek"`
+		Hari     int            `json:"hari"`
+		NamaHari string         `json:"nama_hari"`
+		Tanggal  *string        `json:"tanggal,omitempty"`
+		Kelas    []ConflictSlot `json:"kelas"`
+	}
+
+	ConflictSlot struct {
+		IDKelas             string `json:"id_kelas"`
+		IDJadwalPerkuliahan *int64 `json:"id_jadwal_perkuliahan,omitempty"`
+		JamMulai            string `json:"jam_mulai"`
+		JamSelesai          string `json:"jam_selesai"`
+	}
+
+	scheduleConflictRow struct {
+		Subjek   string  `gorm:"column:subjek"`
+		KelasA   string  `gorm:"column:kelas_a"`
+		KelasB   string  `gorm:"column:kelas_b"`
+		SesiA    *int64  `gorm:"column:sesi_a"`
+		SesiB    *int64  `gorm:"column:sesi_b"`
+		Hari     int     `gorm:"column:hari"`
+		Tanggal  *string `gorm:"column:tanggal"`
+		MulaiA   string  `gorm:"column:mulai_a"`
+		SelesaiA string  `gorm:"column:selesai_a"`
+		MulaiB   string  `gorm:"column:mulai_b"`
+		SelesaiB string  `gorm:"column:selesai_b"`
+	}
+
+	// scheduleConflictSchema membedakan tabel kelas, dosen pengajar dan peserta pada skema Misca dan Smart.
+	scheduleConflictSchema struct {
+		activeSemester func() (string, error)
+		kelasTable     string
+		ajarTable      string
+		ptkColumn      string
+		pdColumn       string
+		// jadwal_perkuliahan hanya tersedia pada Misca
+		sessions bool
+	}
+)
+
+func (a *ApplicationServer) GetScheduleConflictsMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetScheduleConflictsSmart(c)
+	}
+
+	return a.getScheduleConflicts(c, scheduleConflictSchema{
+		activeSemester: a.getActiveSemesterIDMisca,
+		kelasTable:     "kelaskuliah",
+		ajarTable:      "akt_mengajar_dosen",
+		ptkColumn:      "id_ptk",
+		pdColumn:       "id_pd",
+		sessions:       true,
+	})
+}
+
+func (a *ApplicationServer) GetScheduleConflictsSmart(c *fiber.Ctx) error {
+	return a.getScheduleConflicts(c, scheduleConflictSchema{
+		activeSemester: a.getActiveSemesterIDSmart,
+		kelasTable:     "kelas_kuliah",
+		ajarTable:      "akt_ajar_dosen",
+		ptkColumn:      "id_reg_ptk",
+		pdColumn:       "id_reg_pd",
+	})
+}
+
+func (a *ApplicationServer) getScheduleConflicts(c *fiber.Ctx, schema scheduleConflictSchema) error {
+	req := new(ScheduleConflictsRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Semester == "" {
+		activeSemester, err := schema.activeSemester()
+		if err != nil {
+			return HandleError(c, err)
+		}
+		req.Semester = activeSemester
+	}
+
+	sources := []string{roomBookingSourceJadwal}
+	if schema.sessions {
+		sources = append(sources, roomBookingSourceSession)
+	}
+
+	response := ScheduleConflictsResponse{
+		Semester: req.Semester,
+		Konflik:  make([]ScheduleConflict, 0),
+	}
+
+	types := []string{conflictTypeDosen, conflictTypeRuangan, conflictTypeMahasiswa}
+	if req.Jenis != "" {
+		types = []string{req.Jenis}
+	}
+
+	for _, jenis := range types {
+		for _, source := range sources {
+			rows := make([]scheduleConflictRow, 0)
+			err := a.db.Raw(schema.conflictQuery(jenis, source), req.Semester, req.Semester, maxScheduleConflictRows+1).Scan(&rows).Error
+			if err != nil {
+				return HandleError(c, err)
+			}
+
+			if len(rows) > maxScheduleConflictRows {
+				rows = rows[:maxScheduleConflictRows]
+				response.Terpotong = true
+			}
+
+			conflicts := groupScheduleConflicts(jenis, source, rows)
+			response.Konflik = append(response.Konflik, conflicts...)
+
+			switch jenis {
+			case conflictTypeDosen:
+				response.Total.Dosen += len(conflicts)
+			case conflictTypeRuangan:
+				response.Total.Ruangan += len(conflicts)
+			case conflictTypeMahasiswa:
+				response.Total.Mahasiswa += len(conflicts)
+			}
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ScheduleConflictsResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data jadwal yang bentrok",
+		Data:    response,
+	})
+}
+
+// conflictQuery membangun self join tabel jadwal (source) untuk pasangan kelas berbeda pada semester yang sama
+// dengan jam beririsan, lalu join ke dosen pengajar, ruangan, atau peserta sesuai jenis konflik.
+// Query membutuhkan tiga parameter: semester kelas a, semester kelas b, dan batas jumlah baris.
+func (s scheduleConflictSchema) conflictQuery(jenis, source string) string {
+	slot, hari, tanggal, sesi := "hari", "a.hari", "NULL", "NULL AS sesi_a, NULL AS sesi_b"
+	if source == roomBookingSourceSession {
+		slot = "tanggal"
+		hari = "DAYOFWEEK(a.tanggal) - 1"
+		tanggal = "DATE_FORMAT(a.tanggal, '%Y-%m-%d')"
+		sesi = "a.id AS sesi_a, b.id AS sesi_b"
+	}
+
+	var subject string
+	joins := make([]string, 0, 2)
+	where := []string{"ka.id_smt = ?", "kb.id_smt = ?"}
+
+	switch jenis {
+	case conflictTypeDosen:
+		subject = "CAST(da." + s.ptkColumn + " AS CHAR)"
+		joins = append(joins,
+			fmt.Sprintf("JOIN %s da ON da.id_kls = a.id_kls", s.ajarTable),
+			fmt.Sprintf("JOIN %s db ON db.id_kls = b.id_kls AND db.%s = da.%s", s.ajarTable, s.ptkColumn, s.ptkColumn),
+		)
+	case conflictTypeRuangan:
+		subject = "CAST(a.id_ruangan AS CHAR)"
+		where = append(where, "a.id_ruangan IS NOT NULL", "b.id_ruangan = a.id_ruangan")
+		if source == roomBookingSourceSession {
+			// sesi online tidak memakai ruangan
+			where = append(where, "a.metode_pembelajaran != 'online'", "b.metode_pembelajaran != 'online'")
+		}
+	case conflictTypeMahasiswa:
+		subject = "CAST(na." + s.pdColumn + " AS CHAR)"
+		joins = append(joins,
+			"JOIN nilai na ON na.id_kls = a.id_kls",
+			fmt.Sprintf("JOIN nilai nb ON nb.id_kls = b.id_kls AND nb.%s = na.%s", s.pdColumn, s.pdColumn),
+		)
+	}
+
+	return fmt.Sprintf(`
+		SELECT
+			%[1]s AS subjek,
+			CAST(a.id_kls AS CHAR) AS kelas_a,
+			CAST(b.id_kls AS CHAR) AS kelas_b,
+			%[2]s,
+			%[3]s AS hari,
+			%[4]s AS tanggal,
+			a.jam_mulai AS mulai_a,
+			a.jam_selesai AS selesai_a,
+			b.jam_mulai AS mulai_b,
+			b.jam_selesai AS selesai_b
+		FROM %[5]s a
+		JOIN %[5]s b ON b.%[6]s = a.%[6]s
+			AND a.id_kls < b.id_kls
+			AND a.jam_mulai < b.jam_selesai
+			AND b.jam_mulai < a.jam_selesai
+		JOIN %[7]s ka ON ka.id_kls = a.id_kls
+		JOIN %[7]s kb ON kb.id_kls = b.id_kls
+		%[8]s
+		WHERE %[9]s
+		ORDER BY hari ASC, tanggal ASC, a.jam_mulai ASC, kelas_a ASC, kelas_b ASC, subjek ASC
+		LIMIT ?`,
+		subject, sesi, hari, tanggal, source, slot, s.kelasTable,
+		strings.Join(joins, "\n\t\t"), strings.Join(where, " AND "),
+	)
+}
+
+// groupScheduleConflicts menggabungkan baris dengan pasangan kelas dan jam yang sama menjadi satu konflik,
+// misalnya banyak mahasiswa yang sama-sama mengambil dua kelas yang bentrok.
+func groupScheduleConflicts(jenis, source string, rows []scheduleConflictRow) []ScheduleConflict {
+	conflicts := make([]ScheduleConflict, 0)
+	index := make(map[string]int)
+
+	for _, row := range rows {
+		key := fmt.Sprintf("%s|%s|%v|%v|%d|%v|%s|%s|%s|%s",
+			row.KelasA, row.KelasB, valueOf(row.SesiA), valueOf(row.SesiB), row.Hari, valueOf(row.Tanggal),
+			row.MulaiA, row.SelesaiA, row.MulaiB, row.SelesaiB)
+
+		if i, ok := index[key]; ok {
+			conflicts[i].Subjek = append(conflicts[i].Subjek, row.Subjek)
+			continue
+		}
+
+		index[key] = len(conflicts)
+		conflicts = append(conflicts, ScheduleConflict{
+			Jenis:    jenis,
+			Sumber:   source,
+			Subjek:   []string{row.Subjek},
+			Hari:     row.Hari,
+			NamaHari: gl.NamaHari(row.Hari),
+			Tanggal:  row.Tanggal,
+			Kelas: []ConflictSlot{
+				{IDKelas: row.KelasA, IDJadwalPerkuliahan: row.SesiA, JamMulai: row.MulaiA, JamSelesai: row.SelesaiA},
+				{IDKelas: row.KelasB, IDJadwalPerkuliahan: row.SesiB, JamMulai: row.MulaiB, JamSelesai: row.SelesaiB},
+			},
+		})
+	}
+
+	return conflicts
+}
+
+// valueOf mengembalikan nilai pointer, atau zero value jika nil.
+func valueOf[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
@@ -2,20 +2,50 @@ package main
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	semesterActiveMisca = "CASE WHEN setting.param = 'periode_berlaku' THEN 1 ELSE 0 END"
+	semesterActiveSmart = "COALESCE(semester.a_periode_aktif, 0)"
+
+	// semester sebelum dan sesudahnya berdasarkan urutan id_smt
+	semesterNeighbours = `
+		(SELECT MAX(prev_smt.id_smt) FROM semester prev_smt WHERE prev_smt.id_smt < semester.id_smt) AS prev_id_smt,
+		(SELECT MIN(next_smt.id_smt) FROM semester next_smt WHERE next_smt.id_smt > semester.id_smt) AS next_id_smt`
 )
 
 type (
-	ListSemestersResponse struct {
-		ID     string `gorm:"column:id_smt" json:"id"`
-		Name   string `gorm:"column:nm_smt" json:"name"`
-		Active uint8  `gorm:"column:active" json:"active"`
+	ListSemestersRequest struct {
+		gl.SyncFilter
+		// tahun awal tahun ajaran, contoh 2024 untuk 20241, 20242, dan 20243
+		Year   string `json:"year" form:"year" query:"year" validate:"omitempty,numeric,len=4"`
+		Active *bool  `json:"active" form:"active" query:"active"`
 	}
 
-	GetActiveSemester struct {
-		ID   string `gorm:"column:id_smt" json:"id"`
-		Name string `gorm:"column:nm_smt" json:"name"`
+	// ListSemestersResponse memiliki bentuk yang sama pada Misca dan Smart. academic_year, year, term, dan term_name
+	// diurai dari id_smt, sedangkan jendela KRS dan pengisian nilai kosong jika tidak tersedia pada skema.
+	ListSemestersResponse struct {
+		ID           string     `gorm:"column:id_smt" json:"id"`
+		Name         string     `gorm:"column:nm_smt" json:"name"`
+		Active       uint8      `gorm:"column:active" json:"active"`
+		AcademicYear string     `gorm:"-" json:"academic_year"`
+		Year         *int       `gorm:"-" json:"year"`
+		Term         *int       `gorm:"-" json:"term"`
+		TermName     string     `gorm:"-" json:"term_name"`
+		StartDate    *time.Time `gorm:"column:tgl_mulai" json:"start_date"`
+		EndDate      *time.Time `gorm:"column:tgl_selesai" json:"end_date"`
+		KrsStart     *time.Time `gorm:"column:mulai_isi_krs" json:"krs_start"`
+		KrsEnd       *time.Time `gorm:"column:akhir_isi_krs" json:"krs_end"`
+		GradingStart *time.Time `gorm:"column:mulai_isi_nilai" json:"grading_start"`
+		GradingEnd   *time.Time `gorm:"column:akhir_isi_nilai" json:"grading_end"`
+		Previous     *string    `gorm:"column:prev_id_smt" json:"previous"`
+		Next         *string    `gorm:"column:next_id_smt" json:"next"`
 	}
 )
 
@@ -24,22 +54,31 @@ func (a *ApplicationServer) ListSemestersMisca(c *fiber.Ctx) error {
 		return a.ListSemestersSmart(c)
 	}
 
-	semesters := make([]ListSemestersResponse, 0)
+	req := new(ListSemestersRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
 
-	err := a.db.
-		Table("semester").
-		Select(`
-			semester.id_smt AS id_smt,
-			semester.nm_smt AS nm_smt,
-			CASE WHEN setting.param = 'periode_berlaku' THEN 1 ELSE 0 END AS active
-		`).
-		Joins("LEFT JOIN setting ON semester.id_smt = setting.value AND setting.param = 'periode_berlaku'").
-		Find(&semesters).Error
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
 
+	q, syncInfo, err := a.listSemestersQueryMisca(req)
 	if err != nil {
 		return HandleError(c, err)
 	}
 
+	semesters := make([]ListSemestersResponse, 0)
+
+	if err := q.Find(&semesters).Error; err != nil {
+		return HandleError(c, err)
+	}
+	setSemesterMetadata(semesters, RequestLang(c))
+
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, semestersTable, semesters, nil)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListSemestersResponse]]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
@@ -47,6 +86,7 @@ func (a *ApplicationServer) ListSemestersMisca(c *fiber.Ctx) error {
 		Message: "Sukses mendapatkan semua data semester",
 		Data: ListDataApiResponseWrapper[ListSemestersResponse]{
 			List: semesters,
+			Sync: syncInfo,
 		},
 	})
 }
@@ -56,44 +96,48 @@ func (a *ApplicationServer) GetActiveSemesterMisca(c *fiber.Ctx) error {
 		return a.GetActiveSemesterSmart(c)
 	}
 
-	var semester GetActiveSemester
-
-	err := a.db.
-		Table("semester").
-		Select(`
-			semester.id_smt AS id_smt,
-			semester.nm_smt AS nm_smt
-		`).
-		Joins("LEFT JOIN setting ON semester.id_smt = setting.value AND setting.param = 'periode_berlaku'").
-		Where("setting.param = 'periode_berlaku'").
-		Scan(&semester).
-		Error
+	semesters := make([]ListSemestersResponse, 0)
 
+	err := a.semestersQueryMisca().Where("setting.param = 'periode_berlaku'").Limit(1).Scan(&semesters).Error
 	if err != nil {
 		return HandleError(c, err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[GetActiveSemester]{
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListSemestersResponse]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
 		Success: true,
 		Message: "Sukses mendapatkan data semester yang aktif",
-		Data:    semester,
+		Data:    activeSemesterResponse(semesters, RequestLang(c)),
 	})
 }
 
 func (a *ApplicationServer) ListSemestersSmart(c *fiber.Ctx) error {
-	semesters := make([]ListSemestersResponse, 0)
+	req := new(ListSemestersRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
 
-	err := a.db.
-		Table("semester").
-		Select(`id_smt, nm_smt, a_periode_aktif AS active`).
-		Find(&semesters).Error
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
 
+	q, syncInfo, err := a.listSemestersQuerySmart(req)
 	if err != nil {
 		return HandleError(c, err)
 	}
 
+	semesters := make([]ListSemestersResponse, 0)
+
+	if err := q.Find(&semesters).Error; err != nil {
+		return HandleError(c, err)
+	}
+	setSemesterMetadata(semesters, RequestLang(c))
+
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, semestersTable, semesters, nil)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListSemestersResponse]]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
@@ -101,23 +145,260 @@ func (a *ApplicationServer) ListSemestersSmart(c *fiber.Ctx) error {
 		Message: "Sukses mendapatkan semua data semester",
 		Data: ListDataApiResponseWrapper[ListSemestersResponse]{
 			List: semesters,
+			Sync: syncInfo,
 		},
 	})
 }
 
 func (a *ApplicationServer) GetActiveSemesterSmart(c *fiber.Ctx) error {
-	var semester GetActiveSemester
+	semesters := make([]ListSemestersResponse, 0)
 
-	err := a.db.Table("semester").Select(`id_smt, nm_smt`).Where("a_periode_aktif = 1").Scan(&semester).Error
+	err := a.semestersQuerySmart().Where("semester.a_periode_aktif = 1").Limit(1).Scan(&semesters).Error
 	if err != nil {
 		return HandleError(c, err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[GetActiveSemester]{
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListSemestersResponse]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
 		Success: true,
 		Message: "Sukses mendapatkan data semester yang aktif",
+		Data:    activeSemesterResponse(semesters, RequestLang(c)),
+	})
+}
+
+func (a *ApplicationServer) GetSemesterMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetSemesterSmart(c)
+	}
+
+	semester, err := findOne[ListSemestersResponse](a.semestersQueryMisca().Where("semester.id_smt = ?", c.Params("id")), "Semester tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+	semester.setMetadata(RequestLang(c))
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListSemestersResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data semester",
 		Data:    semester,
 	})
 }
+
+func (a *ApplicationServer) GetSemesterSmart(c *fiber.Ctx) error {
+	semester, err := findOne[ListSemestersResponse](a.semestersQuerySmart().Where("semester.id_smt = ?", c.Params("id")), "Semester tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+	semester.setMetadata(RequestLang(c))
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListSemestersResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data semester",
+		Data:    semester,
+	})
+}
+
+// listSemestersQueryMisca membangun query list semester (filter dan sync) tanpa paginasi
+func (a *ApplicationServer) listSemestersQueryMisca(req *ListSemestersRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	q := applySemesterFilter(a.semestersQueryMisca(), req, semesterActiveMisca)
+
+	// tabel semester tidak memiliki timestamp, selalu snapshot penuh
+	return a.ApplySyncFilter(q, req.SyncFilter, nil)
+}
+
+// listSemestersQuerySmart membangun query list semester (filter dan sync) tanpa paginasi
+func (a *ApplicationServer) listSemestersQuerySmart(req *ListSemestersRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	q := applySemesterFilter(a.semestersQuerySmart(), req, semesterActiveSmart)
+
+	// tabel semester tidak memiliki timestamp, selalu snapshot penuh
+	return a.ApplySyncFilter(q, req.SyncFilter, nil)
+}
+
+// semestersQueryMisca adalah query dasar semester yang dipakai oleh list dan detail.
+// Jendela KRS dan pengisian nilai diatur per prodi pada tabel sms untuk periode berlaku, sehingga hanya
+// diisi pada semester aktif dengan rentang terluas dari seluruh prodi.
+func (a *ApplicationServer) semestersQueryMisca() *gorm.DB {
+	return a.db.
+		Table("semester").
+		Select(`
+			semester.id_smt AS id_smt,
+			semester.nm_smt AS nm_smt,
+			` + semesterActiveMisca + ` AS active,
+			semester.tgl_mulai AS tgl_mulai,
+			semester.tgl_selesai AS tgl_selesai,
+			CASE WHEN setting.param = 'periode_berlaku' THEN (SELECT MIN(sms.mulai_isi_krs) FROM sms) END AS mulai_isi_krs,
+			CASE WHEN setting.param = 'periode_berlaku' THEN (SELECT MAX(sms.akhir_isi_krs) FROM sms) END AS akhir_isi_krs,
+			CASE WHEN setting.param = 'periode_berlaku' THEN (SELECT MIN(sms.mulai_isi_nilai) FROM sms) END AS mulai_isi_nilai,
+			CASE WHEN setting.param = 'periode_berlaku' THEN (SELECT MAX(sms.akhir_isi_nilai) FROM sms) END AS akhir_isi_nilai,
+		` + semesterNeighbours).
+		Joins("LEFT JOIN setting ON semester.id_smt = setting.value AND setting.param = 'periode_berlaku'")
+}
+
+// semestersQuerySmart adalah query dasar semester yang dipakai oleh list dan detail.
+// Skema Smart tidak memiliki jendela KRS dan pengisian nilai.
+func (a *ApplicationServer) semestersQuerySmart() *gorm.DB {
+	return a.db.
+		Table("semester").
+		Select(`
+			semester.id_smt AS id_smt,
+			semester.nm_smt AS nm_smt,
+			` + semesterActiveSmart + ` AS active,
+			semester.tgl_mulai AS tgl_mulai,
+			semester.tgl_selesai AS tgl_selesai,
+			NULL AS mulai_isi_krs,
+			NULL AS akhir_isi_krs,
+			NULL AS mulai_isi_nilai,
+			NULL AS akhir_isi_nilai,
+		` + semesterNeighbours)
+}
+
+// applySemesterFilter menambahkan filter ?year= (dari id_smt) dan ?active= (dari ekspresi active) ke query semester.
+func applySemesterFilter(q *gorm.DB, req *ListSemestersRequest, active string) *gorm.DB {
+	if req.Year != "" {
+		q = q.Where("LEFT(semester.id_smt, 4) = ?", req.Year)
+	}
+
+	if req.Active != nil {
+		value := 0
+		if *req.Active {
+			value = 1
+		}
+		q = q.Where(active+" = ?", value)
+	}
+
+	return q
+}
+
+// setMetadata mengisi tahun ajaran dan jenis semester dari id_smt, dibiarkan kosong jika id_smt tidak berformat TTTTS.
+func (s *ListSemestersResponse) setMetadata(lang string) {
+	id, err := gl.ParseSemesterID(s.ID)
+	if err != nil {
+		return
+	}
+
+	s.AcademicYear = id.AcademicYear()
+	s.Year = &id.Year
+	s.Term = &id.Term
+	s.TermName = gl.TermName(id.Term, lang)
+}
+
+func setSemesterMetadata(semesters []ListSemestersResponse, lang string) {
+	for i := range semesters {
+		semesters[i].setMetadata(lang)
+	}
+}
+
+// activeSemesterResponse mengembalikan semester aktif, atau data kosong jika belum ada semester aktif.
+func activeSemesterResponse(semesters []ListSemestersResponse, lang string) ListSemestersResponse {
+	if len(semesters) == 0 {
+		return ListSemestersResponse{}
+	}
+
+	semester := semesters[0]
+	semester.setMetadata(lang)
+	return semester
+}
+
+func (a *ApplicationServer) ExportSemestersMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ExportSemestersSmart(c)
+	}
+
+	req := new(ListSemestersRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listSemestersQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	lang := RequestLang(c)
+	return StreamNDJSON(c, q, syncInfo, func(semesters []ListSemestersResponse) ([]ListSemestersResponse, error) {
+		setSemesterMetadata(semesters, lang)
+		return semesters, nil
+	})
+}
+
+func (a *ApplicationServer) ExportSemestersSmart(c *fiber.Ctx) error {
+	req := new(ListSemestersRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listSemestersQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	lang := RequestLang(c)
+	return StreamNDJSON(c, q, syncInfo, func(semesters []ListSemestersResponse) ([]ListSemestersResponse, error) {
+		setSemesterMetadata(semesters, lang)
+		return semesters, nil
+	})
+}
+
+var semestersTable = TabularTable[ListSemestersResponse]{
+	Name: "semester",
+	Columns: []TabularColumn{
+		{HeaderID: "ID Semester", HeaderEN: "Semester ID"},
+		{HeaderID: "Nama Semester", HeaderEN: "Semester Name"},
+		{HeaderID: "Aktif", HeaderEN: "Active"},
+		{HeaderID: "Tahun Ajaran", HeaderEN: "Academic Year"},
+		{HeaderID: "Jenis Semester", HeaderEN: "Term"},
+		{HeaderID: "Tanggal Mulai", HeaderEN: "Start Date"},
+		{HeaderID: "Tanggal Selesai", HeaderEN: "End Date"},
+		{HeaderID: "Mulai KRS", HeaderEN: "Course Registration Start"},
+		{HeaderID: "Akhir KRS", HeaderEN: "Course Registration End"},
+		{HeaderID: "Mulai Isi Nilai", HeaderEN: "Grading Start"},
+		{HeaderID: "Akhir Isi Nilai", HeaderEN: "Grading End"},
+		{HeaderID: "Semester Sebelumnya", HeaderEN: "Previous Semester"},
+		{HeaderID: "Semester Berikutnya", HeaderEN: "Next Semester"},
+	},
+	Rows: func(s ListSemestersResponse) [][]string {
+		return [][]string{{
+			s.ID, s.Name, strconv.Itoa(int(s.Active)), s.AcademicYear, s.TermName,
+			tabularTime(s.StartDate), tabularTime(s.EndDate),
+			tabularTime(s.KrsStart), tabularTime(s.KrsEnd),
+			tabularTime(s.GradingStart), tabularTime(s.GradingEnd),
+			tabularString(s.Previous), tabularString(s.Next),
+		}}
+	},
+}
+
+// getActiveSemesterIDMisca mengembalikan id_smt dari setting periode_berlaku.
+func (a *ApplicationServer) getActiveSemesterIDMisca() (string, error) {
+	return activeSemesterIDMisca(a.db)
+}
+
+// getActiveSemesterIDSmart mengembalikan id_smt dengan a_periode_aktif = 1.
+func (a *ApplicationServer) getActiveSemesterIDSmart() (string, error) {
+	return activeSemesterIDSmart(a.db)
+}
+
+// activeSemesterIDMisca sama dengan getActiveSemesterIDMisca untuk db tertentu, misalnya transaksi.
+func activeSemesterIDMisca(db *gorm.DB) (string, error) {
+	var activeSemester string
+	err := db.Table("setting").Where("param = ?", "periode_berlaku").Select("value").Scan(&activeSemester).Error
+	return activeSemester, err
+}
+
+func activeSemesterIDSmart(db *gorm.DB) (string, error) {
+	var activeSemester string
+	err := db.Table("semester").Select("id_smt").Where("a_periode_aktif = 1").Scan(&activeSemester).Error
+	return activeSemester, err
+}
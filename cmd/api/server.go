@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/healthcheck"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	gl "lab.garudacyber.co.id/g-learning-connector"
 )
 
@@ -44,29 +46,112 @@ func (a *ApplicationServer) SetupHealthCheckRoutes() {
 }
 
 func (a *ApplicationServer) SetupRoutes() {
+	// /api/misca adalah v1 yang dibekukan, perubahan skema hanya dilakukan di /api/v2
+	a.router.Use(strings.TrimSuffix(apiV1Prefix, "/"), a.WithV1Deprecation())
+
 	a.router.Get("/api/misca/semesters", a.WithApiKey(), a.ListSemestersMisca)
 	a.router.Get("/api/misca/semesters/active", a.WithApiKey(), a.GetActiveSemesterMisca)
+	a.router.Get("/api/misca/semesters/deletions", a.WithApiKey(), a.ListDeletions(semesterDeletionSourceMisca, semesterDeletionSourceSmart))
+	a.router.Get("/api/misca/semesters/export", a.WithApiKey(), a.ExportSemestersMisca)
+	a.router.Get("/api/misca/semesters/:id", a.WithApiKey(), a.GetSemesterMisca)
 
 	a.router.Get("/api/misca/students", a.WithApiKey(), a.ListStudentsMisca)
 	a.router.Get("/api/misca/students/total", a.WithApiKey(), a.GetTotalStudentsMisca)
+	a.router.Get("/api/misca/students/deletions", a.WithApiKey(), a.ListDeletions(studentDeletionSourceMisca, studentDeletionSourceSmart))
+	a.router.Get("/api/misca/students/export", a.WithApiKey(), a.ExportStudentsMisca)
+	a.router.Get("/api/misca/students/by-nik/:nik", a.WithApiKey(), a.GetStudentByNIKMisca)
+	a.router.Get("/api/misca/students/:id", a.WithApiKey(), a.GetStudentMisca)
+	a.router.Get("/api/misca/students/:id/history", a.WithApiKey(), a.GetStudentHistoryMisca)
 
 	a.router.Get("/api/misca/lecturers", a.WithApiKey(), a.ListLecturerMisca)
 	a.router.Get("/api/misca/lecturers/total", a.WithApiKey(), a.GetTotalLecturerMisca)
+	a.router.Get("/api/misca/lecturers/deletions", a.WithApiKey(), a.ListDeletions(lecturerDeletionSourceMisca, lecturerDeletionSourceSmart))
+	a.router.Get("/api/misca/lecturers/export", a.WithApiKey(), a.ExportLecturerMisca)
+	a.router.Get("/api/misca/lecturers/:id", a.WithApiKey(), a.GetLecturerMisca)
+	a.router.Get("/api/misca/lecturers/:id/classes", a.WithApiKey(), a.GetLecturerTeachingLoadMisca)
 
 	a.router.Get("/api/misca/classes", a.WithApiKey(), a.ListKelasMisca)
 	a.router.Get("/api/misca/classes/total", a.WithApiKey(), a.TotalKelasMisca)
+	a.router.Get("/api/misca/classes/deletions", a.WithApiKey(), a.ListDeletions(kelasDeletionSourceMisca, kelasDeletionSourceSmart))
+	a.router.Get("/api/misca/classes/export", a.WithApiKey(), a.ExportKelasMisca)
+	a.router.Get("/api/misca/classes/:id", a.WithApiKey(), a.GetKelasMisca)
+	a.router.Get("/api/misca/classes/:id/students", a.WithApiKey(), a.ListClassStudentsMisca)
+	a.router.Get("/api/misca/classes/:id/grades", a.WithApiKey(), a.ListClassGradesMisca)
+	a.router.Post("/api/misca/classes/:id/grades", a.WithApiKey(), a.WithWriteApiKey(), a.WriteClassGradesMisca)
+	a.router.Get("/api/misca/classes/:id/grading-scheme", a.WithApiKey(), a.GetClassGradingSchemeMisca)
+	a.router.Get("/api/misca/classes/:id/grading-weights", a.WithApiKey(), a.GetClassGradingWeightsMisca)
+	a.router.Put("/api/misca/classes/:id/grading-weights", a.WithApiKey(), a.WithWriteApiKey(), a.UpdateClassGradingWeightsMisca)
 
 	a.router.Get("/api/misca/student_classes", a.WithApiKey(), a.ListSimpleStudentKelasMisca)
 	a.router.Get("/api/misca/student_classes/total", a.WithApiKey(), a.TotalListSimpleStudentKelasMisca)
+	a.router.Get("/api/misca/student_classes/deletions", a.WithApiKey(), a.ListDeletions(studentKelasDeletionSourceMisca, studentKelasDeletionSourceSmart))
+	a.router.Get("/api/misca/student_classes/export", a.WithApiKey(), a.ExportSimpleStudentKelasMisca)
 
 	a.router.Get("/api/misca/student_classes_details", a.WithApiKey(), a.ListStudentKelasDetailsMisca)
 	a.router.Get("/api/misca/student_classes_details/total", a.WithApiKey(), a.GetTotalKelasDetailsMisca)
+	a.router.Get("/api/misca/student_classes_details/export", a.WithApiKey(), a.ExportStudentKelasDetailsMisca)
 
 	a.router.Get("/api/misca/rooms", a.WithApiKey(), a.ListRoomsMisca)
 	a.router.Get("/api/misca/rooms/total", a.WithApiKey(), a.GetTotalRoomsMisca)
+	a.router.Get("/api/misca/rooms/deletions", a.WithApiKey(), a.ListDeletions(roomDeletionSourceMisca, roomDeletionSourceSmart))
+	a.router.Get("/api/misca/rooms/export", a.WithApiKey(), a.ExportRoomsMisca)
+	a.router.Get("/api/misca/rooms/availability", a.WithApiKey(), a.GetRoomAvailabilityMisca)
+	a.router.Get("/api/misca/rooms/:id", a.WithApiKey(), a.GetRoomMisca)
+	a.router.Get("/api/misca/rooms/:id/schedule", a.WithApiKey(), a.GetRoomScheduleMisca)
 
 	a.router.Get("/api/misca/sms", a.WithApiKey(), a.ListSMSMisca)
 	a.router.Get("/api/misca/sms/total", a.WithApiKey(), a.GetTotalSMSMisca)
+	a.router.Get("/api/misca/sms/deletions", a.WithApiKey(), a.ListDeletions(smsDeletionSourceMisca, smsDeletionSourceSmart))
+	a.router.Get("/api/misca/sms/export", a.WithApiKey(), a.ExportSMSMisca)
+	a.router.Get("/api/misca/sms/:id", a.WithApiKey(), a.GetSMSMisca)
+
+	a.router.Post("/api/misca/sessions/:id/attendance", a.WithApiKey(), a.WithWriteApiKey(), a.WithIdempotencyKey(), a.SubmitSessionAttendanceMisca)
+	a.router.Post("/api/misca/sessions/:id/start", a.WithApiKey(), a.WithWriteApiKey(), a.StartSessionMisca)
+	a.router.Post("/api/misca/sessions/:id/end", a.WithApiKey(), a.WithWriteApiKey(), a.EndSessionMisca)
+	a.router.Put("/api/misca/sessions/:id/url", a.WithApiKey(), a.WithWriteApiKey(), a.AttachSessionURLMisca)
+
+	a.router.Get("/api/misca/grading-schemes", a.WithApiKey(), a.ListGradingSchemesMisca)
+	a.router.Get("/api/misca/grading-schemes/resolve", a.WithApiKey(), a.ResolveGradingSchemeMisca)
+
+	a.router.Get("/api/misca/schedule/conflicts", a.WithApiKey(), a.GetScheduleConflictsMisca)
+
+	a.router.Get("/api/misca/stats/students", a.WithApiKey(), a.StudentStatsMisca)
+	a.router.Get("/api/misca/stats/classes", a.WithApiKey(), a.ClassStatsMisca)
+	a.router.Get("/api/misca/stats/overview", a.WithApiKey(), a.StatsOverviewMisca)
+
+	a.router.Get("/api/v2/semesters", a.WithApiKey(), a.ListSemestersV2)
+	a.router.Get("/api/v2/semesters/active", a.WithApiKey(), a.GetActiveSemesterV2)
+	a.router.Get("/api/v2/semesters/deletions", a.WithApiKey(), a.ListDeletions(semesterDeletionSourceMisca, semesterDeletionSourceSmart))
+	a.router.Get("/api/v2/semesters/:id", a.WithApiKey(), a.GetSemesterV2)
+
+	a.router.Get("/api/v2/students", a.WithApiKey(), a.ListStudentsV2)
+	a.router.Get("/api/v2/students/deletions", a.WithApiKey(), a.ListDeletions(studentDeletionSourceMisca, studentDeletionSourceSmart))
+	a.router.Get("/api/v2/students/:id", a.WithApiKey(), a.GetStudentV2)
+
+	a.router.Get("/api/v2/lecturers", a.WithApiKey(), a.ListLecturersV2)
+	a.router.Get("/api/v2/lecturers/deletions", a.WithApiKey(), a.ListDeletions(lecturerDeletionSourceMisca, lecturerDeletionSourceSmart))
+	a.router.Get("/api/v2/lecturers/:id", a.WithApiKey(), a.GetLecturerV2)
+
+	a.router.Get("/api/v2/classes", a.WithApiKey(), a.ListClassesV2)
+	a.router.Get("/api/v2/classes/deletions", a.WithApiKey(), a.ListDeletions(kelasDeletionSourceMisca, kelasDeletionSourceSmart))
+	a.router.Get("/api/v2/classes/:id", a.WithApiKey(), a.GetClassV2)
+
+	a.router.Get("/api/v2/rooms", a.WithApiKey(), a.ListRoomsV2)
+	a.router.Get("/api/v2/rooms/deletions", a.WithApiKey(), a.ListDeletions(roomDeletionSourceMisca, roomDeletionSourceSmart))
+	a.router.Get("/api/v2/rooms/:id", a.WithApiKey(), a.GetRoomV2)
+
+	a.router.Get("/api/v2/study-programs", a.WithApiKey(), a.ListStudyProgramsV2)
+	a.router.Get("/api/v2/study-programs/deletions", a.WithApiKey(), a.ListDeletions(smsDeletionSourceMisca, smsDeletionSourceSmart))
+	a.router.Get("/api/v2/study-programs/:id", a.WithApiKey(), a.GetStudyProgramV2)
+
+	a.router.Post("/api/misca/calendar/tokens", a.WithApiKey(), a.WithWriteApiKey(), a.CreateCalendarTokenMisca)
+	a.router.Delete("/api/misca/calendar/tokens/:id", a.WithApiKey(), a.WithWriteApiKey(), a.RevokeCalendarTokenMisca)
+
+	// feed .ics memakai token kalender pada query ?token= sebagai pengganti api key
+	a.router.Get("/api/calendar/students/:id.ics", a.WithCalendarToken(calendarSubjectMahasiswa), a.CalendarFeedMisca(calendarSubjectMahasiswa))
+	a.router.Get("/api/calendar/lecturers/:id.ics", a.WithCalendarToken(calendarSubjectDosen), a.CalendarFeedMisca(calendarSubjectDosen))
+	a.router.Get("/api/calendar/rooms/:id.ics", a.WithCalendarToken(calendarSubjectRuangan), a.CalendarFeedMisca(calendarSubjectRuangan))
+	a.router.Get("/api/calendar/classes/:id.ics", a.WithCalendarToken(calendarSubjectKelas), a.CalendarFeedMisca(calendarSubjectKelas))
 }
 
 func (a *ApplicationServer) Run() {
@@ -144,6 +229,8 @@ func GetUnsurNilai(db *gorm.DB, idSMS, idSMT, tipeKuliah, tipePenilaian string)
 
 	if tipePenilaian == "detail" {
 		query = query.Where("tipe_kuliah = ? OR tipe_kuliah = ?", tipeKuliah, "teori_praktikum")
+		// utamakan unsur dengan tipe_kuliah yang sama sebelum fallback ke teori_praktikum
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{SQL: "tipe_kuliah = ? DESC", Vars: []interface{}{tipeKuliah}}})
 	}
 
 	result := query.First(&unsur)
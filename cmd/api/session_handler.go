@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	sessionAuditResource = "sessions"
+
+	sessionActionStart     = "start"
+	sessionActionEnd       = "end"
+	sessionActionAttachURL = "attach_url"
+)
+
+type (
+	AttachSessionURLRequest struct {
+		URL string `json:"url" validate:"required,url,max=2048"`
+	}
+
+	SessionTransitionResponse struct {
+		JadwalPerkuliahan
+		Aksi  string    `json:"aksi"`
+		Waktu time.Time `json:"waktu"`
+	}
+)
+
+var (
+	ErrSessionSmart       = errors.New("sesi perkuliahan hanya tersedia untuk instansi Misca")
+	ErrSessionURLOffline  = errors.New("url hanya bisa dipasang pada sesi online atau hybrid")
+	ErrSessionURLFinished = errors.New("url tidak bisa diubah pada sesi yang sudah selesai")
+	ErrInvalidSessionURL  = errors.New("url harus menggunakan http atau https")
+)
+
+func (a *ApplicationServer) StartSessionMisca(c *fiber.Ctx) error {
+	return a.transitionSession(c, gl.SessionStatusDimulai, sessionActionStart, gl.SessionWindow.CanStart)
+}
+
+func (a *ApplicationServer) EndSessionMisca(c *fiber.Ctx) error {
+	return a.transitionSession(c, gl.SessionStatusSelesai, sessionActionEnd, gl.SessionWindow.CanEnd)
+}
+
+// transitionSession mengubah status sesi jika perpindahan status dan waktunya valid,
+// lalu mencatatnya di audit log dalam transaksi yang sama.
+func (a *ApplicationServer) transitionSession(c *fiber.Ctx, to, action string, allowed func(gl.SessionWindow, time.Time) error) error {
+	if IsSmartInstansi(c) {
+		return HandleError(c, NewNotImplementedError(ErrSessionSmart))
+	}
+
+	var sesi JadwalPerkuliahan
+	now := time.Now()
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := findSessionForUpdate(tx, c.Params("id"), &sesi); err != nil {
+			return err
+		}
+
+		if err := gl.ValidateSessionTransition(sesi.Status, to); err != nil {
+			return NewAppError(http.StatusConflict, err.Error(), err)
+		}
+
+		window, err := gl.NewSessionWindow(sesi.Tanggal, sesi.JamMulai, sesi.JamSelesai, time.Local)
+		if err != nil {
+			return err
+		}
+
+		if err := allowed(window, now); err != nil {
+			return NewAppError(http.StatusUnprocessableEntity, err.Error(), err)
+		}
+
+		before := sesi
+		sesi.Status = to
+		sesi.UpdatedAt = &now
+
+		err = tx.Model(&JadwalPerkuliahan{}).
+			Where("id = ?", sesi.ID).
+			Updates(map[string]interface{}{"status": sesi.Status, "updated_at": now}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return writeAuditLog(c, tx, sessionAuditResource, strconv.FormatInt(sesi.ID, 10), action, before, sesi)
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[SessionTransitionResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mengubah status sesi perkuliahan",
+		Data:    SessionTransitionResponse{JadwalPerkuliahan: sesi, Aksi: action, Waktu: now},
+	})
+}
+
+// AttachSessionURLMisca memasang url meeting online pada sesi yang belum selesai.
+func (a *ApplicationServer) AttachSessionURLMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return HandleError(c, NewNotImplementedError(ErrSessionSmart))
+	}
+
+	req := new(AttachSessionURLRequest)
+	if err := c.BodyParser(req); err != nil {
+		return HandleError(c, NewBadRequestError(err))
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return HandleError(c, NewBadRequestError(ErrInvalidSessionURL))
+	}
+
+	var sesi JadwalPerkuliahan
+	now := time.Now()
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := findSessionForUpdate(tx, c.Params("id"), &sesi); err != nil {
+			return err
+		}
+
+		if sesi.Status == gl.SessionStatusSelesai {
+			return NewAppError(http.StatusConflict, ErrSessionURLFinished.Error(), ErrSessionURLFinished)
+		}
+
+		if !slices.Contains([]string{"online", "hybrid"}, sesi.MetodePembelajaran) {
+			return NewAppError(http.StatusUnprocessableEntity, ErrSessionURLOffline.Error(), ErrSessionURLOffline)
+		}
+
+		before := sesi
+		sesi.URL = &req.URL
+		sesi.UpdatedAt = &now
+
+		err := tx.Model(&JadwalPerkuliahan{}).
+			Where("id = ?", sesi.ID).
+			Updates(map[string]interface{}{"url": req.URL, "updated_at": now}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return writeAuditLog(c, tx, sessionAuditResource, strconv.FormatInt(sesi.ID, 10), sessionActionAttachURL, before, sesi)
+	})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[SessionTransitionResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses menyimpan url sesi perkuliahan",
+		Data:    SessionTransitionResponse{JadwalPerkuliahan: sesi, Aksi: sessionActionAttachURL, Waktu: now},
+	})
+}
+
+func findSessionForUpdate(tx *gorm.DB, id string, sesi *JadwalPerkuliahan) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Take(sesi).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NewNotFoundError("Sesi perkuliahan tidak ditemukan")
+	}
+	return err
+}
@@ -4,29 +4,55 @@ import (
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gl "lab.garudacyber.co.id/g-learning-connector"
 )
 
+type (
+	ListSMSRequest struct {
+		gl.Filter
+		gl.SyncFilter
+		// flat=true mengembalikan list datar seperti sebelumnya, default tree
+		Flat bool `json:"flat" form:"flat" query:"flat"`
+	}
+
+	SMSTreeNode struct {
+		SMS
+		Children []*SMSTreeNode `json:"children"`
+	}
+
+	// smsSource membedakan query dan kolom sms pada skema Misca dan Smart.
+	smsSource struct {
+		base       func() *gorm.DB
+		list       func(req *ListSyncRequest) (*gorm.DB, *gl.SyncInfo, error)
+		kodeColumn string
+	}
+)
+
+func NewListSMSRequest() *ListSMSRequest {
+	return &ListSMSRequest{
+		Filter: gl.NewFilterPagination(),
+	}
+}
+
+// ParentID mengembalikan id_induk_sms, string kosong jika sms tidak memiliki induk.
+func (s SMS) ParentID() string {
+	if s.IDIndukSms == nil {
+		return ""
+	}
+	return *s.IDIndukSms
+}
+
 func (a *ApplicationServer) ListSMSMisca(c *fiber.Ctx) error {
 	if IsSmartInstansi(c) {
 		return a.ListSMSSmart(c)
 	}
 
-	sms := make([]SMS, 0)
-	if err := a.db.Table("sms").
-		Select("sms.*,jenjang_pendidikan.nama_jenjang_didik AS nama_jenjang_didik").
-		Joins("LEFT JOIN jenjang_pendidikan ON sms.id_jenj_didik = jenjang_pendidikan.id_jenjang_didik").
-		Scan(&sms).Error; err != nil {
-		return HandleError(c, err)
-	}
-
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[SMS]]{
-		Code:    fiber.StatusOK,
-		Status:  http.StatusText(fiber.StatusOK),
-		Success: true,
-		Message: "Sukses mendapatkan data ruangan",
-		Data: ListDataApiResponseWrapper[SMS]{
-			List: sms,
-		},
+	return a.listSMS(c, smsSource{
+		base:       a.smsQueryMisca,
+		list:       a.listSMSQueryMisca,
+		kodeColumn: "sms.kode_sms",
 	})
 }
 
@@ -53,31 +79,155 @@ func (a *ApplicationServer) GetTotalSMSMisca(c *fiber.Ctx) error {
 }
 
 func (a *ApplicationServer) ListSMSSmart(c *fiber.Ctx) error {
-	var sms []SMS
-	if err := a.db.Table("sms").
-		Select(`
-				sms.id_sms AS id_sms,
-				sms.nm_lemb AS nm_lemb,
-				sms.nm_lemb_english AS nm_lemb_inggris,
-				sms.kode_prodi AS kode_sms,
-				sms.id_jns_sms,
-				jenjang_pendidikan.nm_jenj_didik AS nama_jenjang_didik`).
-		Joins("LEFT JOIN jenjang_pendidikan ON sms.id_jenj_didik = jenjang_pendidikan.id_jenj_didik").
-		Scan(&sms).Error; err != nil {
+	return a.listSMS(c, smsSource{
+		base:       a.smsQuerySmart,
+		list:       a.listSMSQuerySmart,
+		kodeColumn: "sms.kode_prodi",
+	})
+}
+
+// listSMS mengembalikan sms sebagai tree (fakultas -> prodi melalui id_induk_sms) atau list datar jika flat=true.
+// Pada tree, keyword dan updated_since menentukan sms yang cocok, lalu induknya ikut ditampilkan supaya
+// jalurnya tetap utuh, dan paginasi berlaku untuk sms teratas (root).
+func (a *ApplicationServer) listSMS(c *fiber.Ctx, src smsSource) error {
+	req := NewListSMSRequest()
+	if err := c.QueryParser(req); err != nil {
 		return HandleError(c, err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[SMS]]{
+	q, syncInfo, err := src.list(&ListSyncRequest{SyncFilter: req.SyncFilter})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Filter.HasKeyword() {
+		q = q.Where("(sms.nm_lemb LIKE ? OR "+src.kodeColumn+" LIKE ?)", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	}
+
+	if req.Filter.HasSort() {
+		q = q.Order(clause.OrderByColumn{
+			Column: clause.Column{Name: req.Filter.SortBy},
+			Desc:   req.Filter.IsDesc(),
+		})
+	} else {
+		q = q.Order("sms.id_sms ASC")
+	}
+
+	offset := req.Filter.GetOffset()
+	limit := req.Filter.GetLimit()
+
+	format := NegotiateTabularFormat(c)
+	if req.Flat || format != "" {
+		var totalData int64
+		if err := q.Count(&totalData).Error; err != nil {
+			return HandleError(c, err)
+		}
+
+		sms := make([]SMS, 0)
+		if err := q.Offset(int(offset)).Limit(int(limit)).Scan(&sms).Error; err != nil {
+			return HandleError(c, err)
+		}
+
+		pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, totalData)
+		if err != nil {
+			return HandleError(c, err)
+		}
+
+		if format != "" {
+			return WriteTabular(c, format, smsTable, sms, pageInfo)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[SMS]]{
+			Code:    fiber.StatusOK,
+			Status:  http.StatusText(fiber.StatusOK),
+			Success: true,
+			Message: "Sukses mendapatkan data sms",
+			Data: ListDataApiResponseWrapper[SMS]{
+				List:     sms,
+				PageInfo: pageInfo,
+				Sync:     syncInfo,
+			},
+		})
+	}
+
+	matched := make([]SMS, 0)
+	if err := q.Scan(&matched).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	all := matched
+	if req.Filter.HasKeyword() || req.SyncFilter.HasUpdatedSince() {
+		// induk dari sms yang cocok belum tentu ikut cocok, ambil seluruh sms untuk menyusun jalurnya
+		all = make([]SMS, 0)
+		if err := src.base().Order("sms.id_sms ASC").Scan(&all).Error; err != nil {
+			return HandleError(c, err)
+		}
+	}
+
+	roots := buildSMSTree(all, matched)
+
+	pageInfo, err := gl.NewPageInfo(req.Filter.CurrentPage, limit, offset, int64(len(roots)))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[*SMSTreeNode]]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
 		Success: true,
-		Message: "Sukses mendapatkan data ruangan",
-		Data: ListDataApiResponseWrapper[SMS]{
-			List: sms,
+		Message: "Sukses mendapatkan data sms",
+		Data: ListDataApiResponseWrapper[*SMSTreeNode]{
+			List:     gl.Paginate(roots, offset, limit),
+			PageInfo: pageInfo,
+			Sync:     syncInfo,
 		},
 	})
 }
 
+// buildSMSTree menyusun tree dari sms yang cocok beserta seluruh induknya. Urutan anak mengikuti urutan matched,
+// sms yang induknya tidak ditemukan dijadikan root.
+func buildSMSTree(all, matched []SMS) []*SMSTreeNode {
+	byID := make(map[string]SMS, len(all))
+	for _, sms := range all {
+		byID[sms.IDSms] = sms
+	}
+
+	nodes := make(map[string]*SMSTreeNode, len(matched))
+	order := make([]string, 0, len(matched))
+
+	var include func(sms SMS)
+	include = func(sms SMS) {
+		if _, ok := nodes[sms.IDSms]; ok {
+			return
+		}
+		nodes[sms.IDSms] = &SMSTreeNode{SMS: sms, Children: []*SMSTreeNode{}}
+		order = append(order, sms.IDSms)
+
+		if parentID := sms.ParentID(); parentID != "" {
+			if parent, ok := byID[parentID]; ok {
+				include(parent)
+			}
+		}
+	}
+
+	for _, sms := range matched {
+		include(sms)
+	}
+
+	roots := make([]*SMSTreeNode, 0)
+	for _, id := range order {
+		node := nodes[id]
+		parent, ok := nodes[node.ParentID()]
+		if !ok || parent == node {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	return roots
+}
+
 func (a *ApplicationServer) GetTotalSMSSmart(c *fiber.Ctx) error {
 	var total int64
 	err := a.db.Table("sms").Count(&total).Error
@@ -95,3 +245,140 @@ func (a *ApplicationServer) GetTotalSMSSmart(c *fiber.Ctx) error {
 		},
 	})
 }
+
+func (a *ApplicationServer) GetSMSMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetSMSSmart(c)
+	}
+
+	sms, err := findOne[SMS](a.smsQueryMisca().Where("sms.id_sms = ?", c.Params("id")), "Program studi tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[SMS]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data sms",
+		Data:    sms,
+	})
+}
+
+func (a *ApplicationServer) GetSMSSmart(c *fiber.Ctx) error {
+	sms, err := findOne[SMS](a.smsQuerySmart().Where("sms.id_sms = ?", c.Params("id")), "Program studi tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[SMS]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data sms",
+		Data:    sms,
+	})
+}
+
+// listSMSQueryMisca membangun query list sms (sync) tanpa paginasi
+func (a *ApplicationServer) listSMSQueryMisca(req *ListSyncRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	return a.ApplySyncFilter(a.smsQueryMisca(), req.SyncFilter, smsSyncConditionsMisca)
+}
+
+// listSMSQuerySmart membangun query list sms (sync) tanpa paginasi
+func (a *ApplicationServer) listSMSQuerySmart(req *ListSyncRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	// tabel sms Smart tidak memiliki timestamp, selalu snapshot penuh
+	return a.ApplySyncFilter(a.smsQuerySmart(), req.SyncFilter, nil)
+}
+
+// smsQueryMisca adalah query dasar sms yang dipakai oleh list dan detail
+func (a *ApplicationServer) smsQueryMisca() *gorm.DB {
+	return a.db.
+		Table("sms").
+		Select("sms.*,jenjang_pendidikan.nama_jenjang_didik AS nama_jenjang_didik").
+		Joins("LEFT JOIN jenjang_pendidikan ON sms.id_jenj_didik = jenjang_pendidikan.id_jenjang_didik")
+}
+
+// smsQuerySmart adalah query dasar sms yang dipakai oleh list dan detail
+func (a *ApplicationServer) smsQuerySmart() *gorm.DB {
+	return a.db.
+		Table("sms").
+		Select(`
+				sms.id_sms AS id_sms,
+				sms.nm_lemb AS nm_lemb,
+				sms.nm_lemb_english AS nm_lemb_inggris,
+				sms.kode_prodi AS kode_sms,
+				sms.id_jenj_didik,
+				sms.id_jns_sms,
+				sms.id_induk_sms,
+				jenjang_pendidikan.nm_jenj_didik AS nama_jenjang_didik`).
+		Joins("LEFT JOIN jenjang_pendidikan ON sms.id_jenj_didik = jenjang_pendidikan.id_jenj_didik")
+}
+
+func (a *ApplicationServer) ExportSMSMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ExportSMSSmart(c)
+	}
+
+	req := new(ListSyncRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listSMSQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, exportIdentity[SMS])
+}
+
+func (a *ApplicationServer) ExportSMSSmart(c *fiber.Ctx) error {
+	req := new(ListSyncRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listSMSQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, exportIdentity[SMS])
+}
+
+var smsTable = TabularTable[SMS]{
+	Name: "sms",
+	Columns: []TabularColumn{
+		{HeaderID: "ID Prodi", HeaderEN: "Study Program ID"},
+		{HeaderID: "Nama Prodi", HeaderEN: "Study Program Name (Indonesian)"},
+		{HeaderID: "Nama Prodi (Inggris)", HeaderEN: "Study Program Name"},
+		{HeaderID: "Kode Prodi", HeaderEN: "Study Program Code"},
+		{HeaderID: "ID Jenjang Didik", HeaderEN: "Education Level ID"},
+		{HeaderID: "Jenjang Didik", HeaderEN: "Education Level"},
+		{HeaderID: "ID Jenis SMS", HeaderEN: "Unit Type ID"},
+		{HeaderID: "ID Induk SMS", HeaderEN: "Parent Unit ID"},
+		{HeaderID: "Gelar", HeaderEN: "Degree"},
+		{HeaderID: "Gelar Singkatan", HeaderEN: "Degree Abbreviation"},
+		{HeaderID: "Lama Studi", HeaderEN: "Study Duration"},
+		{HeaderID: "Buka KRS", HeaderEN: "Course Registration Open"},
+		{HeaderID: "Buka Nilai", HeaderEN: "Grading Open"},
+	},
+	Rows: func(s SMS) [][]string {
+		return [][]string{{
+			s.IDSms,
+			s.NmLemb,
+			tabularString(s.NmLembInggris),
+			tabularString(s.KodeSms),
+			tabularInt(s.IDJenjangDidik),
+			tabularString(s.NamaJenjangDidik),
+			tabularInt(s.IDJenisSms),
+			tabularString(s.IDIndukSms),
+			tabularString(s.Gelar),
+			tabularString(s.GelarSingkatan),
+			tabularString(s.LamaStudi),
+			tabularBool(s.BukaKrs),
+			tabularBool(s.BukaNilai),
+		}}
+	},
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	statsDimensionSMS      = "id_sms"
+	statsDimensionAngkatan = "angkatan"
+	statsDimensionGender   = "gender"
+	statsDimensionSemester = "semester"
+	statsDimensionMetode   = "metode_pembelajaran"
+)
+
+type (
+	StatsRequest struct {
+		// dimensi dipisah koma, contoh id_sms,angkatan. Default id_sms
+		GroupBy  string `json:"group_by" form:"group_by" query:"group_by"`
+		Semester string `json:"semester" form:"semester" query:"semester"`
+	}
+
+	StatsResponse struct {
+		GroupBy  []string     `json:"group_by"`
+		Semester string       `json:"semester,omitempty"`
+		Total    int64        `json:"total"`
+		Groups   []StatsGroup `json:"groups"`
+	}
+
+	// StatsGroup berisi nilai setiap dimensi group_by (null jika kosong) dan jumlah datanya.
+	StatsGroup struct {
+		Group map[string]*string `json:"group"`
+		Total int64              `json:"total"`
+	}
+
+	StatsOverviewRequest struct {
+		Semester string `json:"semester" form:"semester" query:"semester"`
+	}
+
+	StatsOverviewResponse struct {
+		Semester          string `json:"semester"`
+		StudentsEnrolled  int64  `json:"students_enrolled"`
+		Classes           int64  `json:"classes"`
+		LecturersTeaching int64  `json:"lecturers_teaching"`
+		SessionsCompleted int64  `json:"sessions_completed"`
+		SessionsPlanned   int64  `json:"sessions_planned"`
+	}
+
+	statsRow struct {
+		IDSMS              *string `gorm:"column:id_sms"`
+		Angkatan           *string `gorm:"column:angkatan"`
+		Gender             *string `gorm:"column:gender"`
+		Semester           *string `gorm:"column:semester"`
+		MetodePembelajaran *string `gorm:"column:metode_pembelajaran"`
+		Total              int64   `gorm:"column:total"`
+	}
+
+	statsDimension struct {
+		column string
+		joins  []string
+	}
+
+	// statsSource menjelaskan tabel, ekspresi jumlah, dan dimensi yang bisa dipakai untuk satu statistik.
+	statsSource struct {
+		activeSemester func() (string, error)
+		table          string
+		joins          []string
+		count          string
+		where          string
+		dimensions     map[string]statsDimension
+		// current dipakai jika semester tidak difilter maupun dikelompokkan, contoh hanya mahasiswa yang belum keluar
+		current string
+		// defaultSemester memfilter semester aktif jika semester tidak difilter maupun dikelompokkan
+		defaultSemester bool
+	}
+)
+
+var ErrInvalidStatsDimension = errors.New("dimensi group_by tidak tersedia")
+
+func (a *ApplicationServer) studentStatsSourceMisca() statsSource {
+	return statsSource{
+		activeSemester: a.getActiveSemesterIDMisca,
+		table:          "mahasiswa_histori",
+		joins:          []string{"JOIN mahasiswa ON mahasiswa.id = mahasiswa_histori.id_mahasiswa"},
+		count:          "COUNT(DISTINCT mahasiswa_histori.id_pd)",
+		where:          "mahasiswa.deleted_at IS NULL",
+		dimensions: map[string]statsDimension{
+			statsDimensionSMS:      {column: "mahasiswa_histori.id_sms"},
+			statsDimensionAngkatan: {column: "mahasiswa_histori.angkatan"},
+			statsDimensionGender:   {column: "mahasiswa.jenis_kelamin"},
+			statsDimensionSemester: {
+				column: "nilai.smt_ambil",
+				joins:  []string{"JOIN nilai ON nilai.id_pd = mahasiswa_histori.id_pd"},
+			},
+		},
+		current: "mahasiswa_histori.id_jns_keluar IS NULL",
+	}
+}
+
+func (a *ApplicationServer) studentStatsSourceSmart() statsSource {
+	return statsSource{
+		activeSemester: a.getActiveSemesterIDSmart,
+		table:          "mahasiswa",
+		count:          "COUNT(DISTINCT mahasiswa.id_pd)",
+		dimensions: map[string]statsDimension{
+			statsDimensionSMS:      {column: "mahasiswa.id_sms"},
+			statsDimensionAngkatan: {column: "LEFT(mahasiswa.mulai_smt, 4)"},
+			statsDimensionGender:   {column: "mahasiswa.jk"},
+			statsDimensionSemester: {
+				column: "kelas_kuliah.id_smt",
+				joins: []string{
+					"JOIN nilai ON nilai.id_reg_pd = mahasiswa.id_pd",
+					"JOIN kelas_kuliah ON kelas_kuliah.id_kls = nilai.id_kls",
+				},
+			},
+		},
+		current: "mahasiswa.id_jns_keluar IS NULL",
+	}
+}
+
+func (a *ApplicationServer) classStatsSourceMisca() statsSource {
+	return statsSource{
+		activeSemester: a.getActiveSemesterIDMisca,
+		table:          "kelaskuliah",
+		count:          "COUNT(DISTINCT kelaskuliah.id_kls)",
+		dimensions: map[string]statsDimension{
+			statsDimensionSMS:      {column: "kelaskuliah.id_sms"},
+			statsDimensionSemester: {column: "kelaskuliah.id_smt"},
+			statsDimensionMetode: {
+				column: "jadwal_perkuliahan.metode_pembelajaran",
+				joins:  []string{"LEFT JOIN jadwal_perkuliahan ON jadwal_perkuliahan.id_kls = kelaskuliah.id_kls"},
+			},
+		},
+		defaultSemester: true,
+	}
+}
+
+// classStatsSourceSmart tidak memiliki dimensi metode_pembelajaran karena Smart tidak memiliki jadwal_perkuliahan.
+func (a *ApplicationServer) classStatsSourceSmart() statsSource {
+	return statsSource{
+		activeSemester: a.getActiveSemesterIDSmart,
+		table:          "kelas_kuliah",
+		count:          "COUNT(DISTINCT kelas_kuliah.id_kls)",
+		dimensions: map[string]statsDimension{
+			statsDimensionSMS:      {column: "kelas_kuliah.id_sms"},
+			statsDimensionSemester: {column: "kelas_kuliah.id_smt"},
+		},
+		defaultSemester: true,
+	}
+}
+
+// StudentStatsMisca mengembalikan jumlah mahasiswa per dimensi id_sms, angkatan, gender, dan semester.
+// Tanpa semester, yang dihitung adalah registrasi yang belum keluar. Dengan semester, yang dihitung adalah
+// mahasiswa yang mengambil kelas (nilai) pada semester tersebut.
+func (a *ApplicationServer) StudentStatsMisca(c *fiber.Ctx) error {
+	src := a.studentStatsSourceMisca()
+	if IsSmartInstansi(c) {
+		src = a.studentStatsSourceSmart()
+	}
+
+	return a.writeStats(c, src, "Sukses mendapatkan statistik mahasiswa")
+}
+
+// ClassStatsMisca mengembalikan jumlah kelas per dimensi id_sms, semester, dan metode_pembelajaran (Misca),
+// default pada semester aktif.
+func (a *ApplicationServer) ClassStatsMisca(c *fiber.Ctx) error {
+	src := a.classStatsSourceMisca()
+	if IsSmartInstansi(c) {
+		src = a.classStatsSourceSmart()
+	}
+
+	return a.writeStats(c, src, "Sukses mendapatkan statistik kelas")
+}
+
+func (a *ApplicationServer) writeStats(c *fiber.Ctx, src statsSource, message string) error {
+	req := new(StatsRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	groupBy, err := parseStatsGroupBy(req.GroupBy, src)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Semester == "" && src.defaultSemester && !slices.Contains(groupBy, statsDimensionSemester) {
+		activeSemester, err := src.activeSemester()
+		if err != nil {
+			return HandleError(c, err)
+		}
+		req.Semester = activeSemester
+	}
+
+	q := a.statsQuery(src, groupBy, req.Semester)
+
+	// total dihitung terpisah karena satu data bisa masuk ke beberapa grup (misalnya beberapa semester)
+	var total int64
+	if err := q.Session(&gorm.Session{}).Select(src.count).Scan(&total).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	columns := make([]string, 0, len(groupBy)+1)
+	groupColumns := make([]string, 0, len(groupBy))
+	for _, dimension := range groupBy {
+		column := src.dimensions[dimension].column
+		columns = append(columns, column+" AS "+dimension)
+		groupColumns = append(groupColumns, column)
+	}
+	columns = append(columns, src.count+" AS total")
+
+	rows := make([]statsRow, 0)
+	err = q.Select(strings.Join(columns, ", ")).
+		Group(strings.Join(groupColumns, ", ")).
+		Order(strings.Join(groupColumns, ", ")).
+		Scan(&rows).Error
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	groups := make([]StatsGroup, 0, len(rows))
+	for _, row := range rows {
+		group := make(map[string]*string, len(groupBy))
+		for _, dimension := range groupBy {
+			group[dimension] = row.value(dimension)
+		}
+		groups = append(groups, StatsGroup{Group: group, Total: row.Total})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[StatsResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: message,
+		Data: StatsResponse{
+			GroupBy:  groupBy,
+			Semester: req.Semester,
+			Total:    total,
+			Groups:   groups,
+		},
+	})
+}
+
+// statsQuery membangun FROM, join, dan filter statistik tanpa SELECT.
+func (a *ApplicationServer) statsQuery(src statsSource, groupBy []string, semester string) *gorm.DB {
+	q := a.db.Table(src.table)
+	for _, join := range src.joins {
+		q = q.Joins(join)
+	}
+	if src.where != "" {
+		q = q.Where(src.where)
+	}
+
+	dimensions := groupBy
+	if semester != "" && !slices.Contains(groupBy, statsDimensionSemester) {
+		dimensions = append(slices.Clone(groupBy), statsDimensionSemester)
+	}
+
+	joined := make(map[string]bool)
+	for _, dimension := range dimensions {
+		for _, join := range src.dimensions[dimension].joins {
+			if !joined[join] {
+				joined[join] = true
+				q = q.Joins(join)
+			}
+		}
+	}
+
+	if semester != "" {
+		q = q.Where(src.dimensions[statsDimensionSemester].column+" = ?", semester)
+	} else if src.current != "" && !slices.Contains(groupBy, statsDimensionSemester) {
+		q = q.Where(src.current)
+	}
+
+	return q
+}
+
+// parseStatsGroupBy memisahkan group_by dan memastikan setiap dimensi tersedia pada sumber statistik.
+func parseStatsGroupBy(value string, src statsSource) ([]string, error) {
+	if value == "" {
+		value = statsDimensionSMS
+	}
+
+	groupBy := make([]string, 0)
+	for _, dimension := range strings.Split(value, ",") {
+		dimension = strings.TrimSpace(dimension)
+		if dimension == "" || slices.Contains(groupBy, dimension) {
+			continue
+		}
+
+		if _, ok := src.dimensions[dimension]; !ok {
+			return nil, NewBadRequestError(errors.Wrapf(ErrInvalidStatsDimension, "dimensi %q", dimension))
+		}
+		groupBy = append(groupBy, dimension)
+	}
+
+	if len(groupBy) == 0 {
+		return nil, NewBadRequestError(errors.Wrap(ErrInvalidStatsDimension, "group_by kosong"))
+	}
+
+	return groupBy, nil
+}
+
+func (r statsRow) value(dimension string) *string {
+	switch dimension {
+	case statsDimensionSMS:
+		return r.IDSMS
+	case statsDimensionAngkatan:
+		return r.Angkatan
+	case statsDimensionGender:
+		return r.Gender
+	case statsDimensionSemester:
+		return r.Semester
+	case statsDimensionMetode:
+		return r.MetodePembelajaran
+	}
+	return nil
+}
+
+// StatsOverviewMisca merangkum satu semester (default semester aktif): mahasiswa yang mengambil kelas, jumlah kelas,
+// dosen yang mengajar, serta sesi yang sudah selesai dibanding rencana pertemuan.
+func (a *ApplicationServer) StatsOverviewMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.StatsOverviewSmart(c)
+	}
+
+	req := new(StatsOverviewRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Semester == "" {
+		activeSemester, err := a.getActiveSemesterIDMisca()
+		if err != nil {
+			return HandleError(c, err)
+		}
+		req.Semester = activeSemester
+	}
+
+	overview := StatsOverviewResponse{Semester: req.Semester}
+	queries := []struct {
+		target *int64
+		query  string
+	}{
+		{&overview.StudentsEnrolled, `SELECT COUNT(DISTINCT nilai.id_pd) FROM nilai
+			JOIN kelaskuliah ON kelaskuliah.id_kls = nilai.id_kls
+			WHERE kelaskuliah.id_smt = ?`},
+		{&overview.Classes, `SELECT COUNT(*) FROM kelaskuliah WHERE kelaskuliah.id_smt = ?`},
+		{&overview.LecturersTeaching, `SELECT COUNT(DISTINCT akt_mengajar_dosen.id_ptk) FROM akt_mengajar_dosen
+			JOIN kelaskuliah ON kelaskuliah.id_kls = akt_mengajar_dosen.id_kls
+			WHERE kelaskuliah.id_smt = ?`},
+		{&overview.SessionsCompleted, `SELECT COUNT(*) FROM jadwal_perkuliahan
+			JOIN kelaskuliah ON kelaskuliah.id_kls = jadwal_perkuliahan.id_kls
+			WHERE kelaskuliah.id_smt = ? AND jadwal_perkuliahan.status = 'selesai'`},
+		// rencana pertemuan dicatat per dosen pengajar, diambil yang terbesar per kelas
+		{&overview.SessionsPlanned, `SELECT COALESCE(SUM(rencana), 0) FROM (
+			SELECT MAX(akt_mengajar_dosen.temu_rencana) AS rencana FROM akt_mengajar_dosen
+			JOIN kelaskuliah ON kelaskuliah.id_kls = akt_mengajar_dosen.id_kls
+			WHERE kelaskuliah.id_smt = ?
+			GROUP BY akt_mengajar_dosen.id_kls
+		) AS rencana_kelas`},
+	}
+
+	for _, q := range queries {
+		if err := a.db.Raw(q.query, req.Semester).Scan(q.target).Error; err != nil {
+			return HandleError(c, err)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[StatsOverviewResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan ringkasan semester",
+		Data:    overview,
+	})
+}
+
+// StatsOverviewSmart memakai realisasi pertemuan akt_ajar_dosen.jml_tm_real sebagai sesi selesai
+// karena Smart tidak memiliki jadwal_perkuliahan.
+func (a *ApplicationServer) StatsOverviewSmart(c *fiber.Ctx) error {
+	req := new(StatsOverviewRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Semester == "" {
+		activeSemester, err := a.getActiveSemesterIDSmart()
+		if err != nil {
+			return HandleError(c, err)
+		}
+		req.Semester = activeSemester
+	}
+
+	overview := StatsOverviewResponse{Semester: req.Semester}
+	queries := []struct {
+		target *int64
+		query  string
+	}{
+		{&overview.StudentsEnrolled, `SELECT COUNT(DISTINCT nilai.id_reg_pd) FROM nilai
+			JOIN kelas_kuliah ON kelas_kuliah.id_kls = nilai.id_kls
+			WHERE kelas_kuliah.id_smt = ?`},
+		{&overview.Classes, `SELECT COUNT(*) FROM kelas_kuliah WHERE kelas_kuliah.id_smt = ?`},
+		{&overview.LecturersTeaching, `SELECT COUNT(DISTINCT akt_ajar_dosen.id_reg_ptk) FROM akt_ajar_dosen
+			JOIN kelas_kuliah ON kelas_kuliah.id_kls = akt_ajar_dosen.id_kls
+			WHERE kelas_kuliah.id_smt = ?`},
+		{&overview.SessionsCompleted, `SELECT COALESCE(SUM(realisasi), 0) FROM (
+			SELECT MAX(akt_ajar_dosen.jml_tm_real) AS realisasi FROM akt_ajar_dosen
+			JOIN kelas_kuliah ON kelas_kuliah.id_kls = akt_ajar_dosen.id_kls
+			WHERE kelas_kuliah.id_smt = ?
+			GROUP BY akt_ajar_dosen.id_kls
+		) AS realisasi_kelas`},
+		{&overview.SessionsPlanned, `SELECT COALESCE(SUM(rencana), 0) FROM (
+			SELECT MAX(akt_ajar_dosen.jml_tm_renc) AS rencana FROM akt_ajar_dosen
+			JOIN kelas_kuliah ON kelas_kuliah.id_kls = akt_ajar_dosen.id_kls
+			WHERE kelas_kuliah.id_smt = ?
+			GROUP BY akt_ajar_dosen.id_kls
+		) AS rencana_kelas`},
+	}
+
+	for _, q := range queries {
+		if err := a.db.Raw(q.query, req.Semester).Scan(q.target).Error; err != nil {
+			return HandleError(c, err)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[StatsOverviewResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan ringkasan semester",
+		Data:    overview,
+	})
+}
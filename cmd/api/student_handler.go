@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	gl "lab.garudacyber.co.id/g-learning-connector"
 )
@@ -15,6 +16,7 @@ type (
 
 	ListStudentsRequest struct {
 		gl.Filter
+		gl.SyncFilter
 	}
 
 	ListStudentsResponse struct {
@@ -49,38 +51,9 @@ func (a *ApplicationServer) ListStudentsMisca(c *fiber.Ctx) error {
 	offset := req.Filter.GetOffset()
 	limit := req.Filter.GetLimit()
 
-	q := a.db.
-		Select(`
-			id,
-			nama_mahasiswa,
-			jenis_kelamin,
-			nik,
-			email,
-			handphone,
-			telepon`).
-		Table("mahasiswa").
-		Where("nik IS NOT NULL AND nik != '' AND LENGTH(nik) = 16 AND deleted_at IS NULL")
-
-	if req.Filter.HasKeyword() {
-		q = q.Where("nama_mahasiswa LIKE ? OR nik LIKE ?", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
-	}
-
-	if req.Filter.HasSort() {
-		sortBy := req.Filter.SortBy
-		if sortBy == "id" {
-			sortBy = "id" // Directly use 'id' as it's from mahasiswa table
-		} else {
-			sortBy = sortBy // Other fields are already from mahasiswa table
-		}
-
-		q = q.Order(
-			clause.OrderByColumn{
-				Column: clause.Column{Name: sortBy},
-				Desc:   req.Filter.IsDesc(),
-			},
-		)
-	} else {
-		q = q.Order("created_at ASC")
+	q, syncInfo, err := a.listStudentsQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
 	}
 
 	// Count total data without offset and limit
@@ -101,6 +74,10 @@ func (a *ApplicationServer) ListStudentsMisca(c *fiber.Ctx) error {
 		return HandleError(c, err)
 	}
 
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, studentsTable, listStudents, pageInfo)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListStudentsResponse]]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
@@ -109,10 +86,101 @@ func (a *ApplicationServer) ListStudentsMisca(c *fiber.Ctx) error {
 		Data: ListDataApiResponseWrapper[ListStudentsResponse]{
 			List:     listStudents,
 			PageInfo: pageInfo,
+			Sync:     syncInfo,
 		},
 	})
 }
 
+// listStudentsQueryMisca membangun query list mahasiswa (filter, sync dan sorting) tanpa paginasi
+func (a *ApplicationServer) listStudentsQueryMisca(req *ListStudentsRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	q := a.studentsQueryMisca()
+
+	if req.Filter.HasKeyword() {
+		q = q.Where("nama_mahasiswa LIKE ? OR nik LIKE ?", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	}
+
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, studentsSyncConditionsMisca)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.Filter.HasSort() {
+		// all sortable fields come from the mahasiswa table
+		q = q.Order(
+			clause.OrderByColumn{
+				Column: clause.Column{Name: req.Filter.SortBy},
+				Desc:   req.Filter.IsDesc(),
+			},
+		)
+	} else {
+		q = q.Order("created_at ASC")
+	}
+
+	return q, syncInfo, nil
+}
+
+// studentsQueryMisca adalah query dasar mahasiswa yang dipakai oleh list dan detail
+func (a *ApplicationServer) studentsQueryMisca() *gorm.DB {
+	return a.db.
+		Select(`
+			id,
+			nama_mahasiswa,
+			jenis_kelamin,
+			nik,
+			email,
+			handphone,
+			telepon`).
+		Table("mahasiswa").
+		Where("nik IS NOT NULL AND nik != '' AND LENGTH(nik) = 16 AND deleted_at IS NULL")
+}
+
+func (a *ApplicationServer) GetStudentMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetStudentSmart(c)
+	}
+
+	student, err := findOne[ListStudentsResponse](a.studentsQueryMisca().Where("id = ?", c.Params("id")), "Mahasiswa tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListStudentsResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data mahasiswa",
+		Data:    student,
+	})
+}
+
+// GetStudentByNIKMisca mencari mahasiswa berdasarkan nik yang sama persis, berbeda dengan
+// keyword pada list yang memakai LIKE. Jika nik dipakai lebih dari satu data, direspon 409 berisi id kandidat
+// supaya client memilih lewat /students/:id.
+func (a *ApplicationServer) GetStudentByNIKMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetStudentByNIKSmart(c)
+	}
+
+	q := a.studentsQueryMisca().Where("nik = ?", c.Params("nik")).Order("created_at ASC")
+
+	student, err := findUnique(q, "Mahasiswa tidak ditemukan", studentID)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListStudentsResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data mahasiswa",
+		Data:    student,
+	})
+}
+
+func studentID(student ListStudentsResponse) string {
+	return student.ID
+}
+
 func (a *ApplicationServer) GetTotalStudentsMisca(c *fiber.Ctx) error {
 	if IsSmartInstansi(c) {
 		return a.GetTotalStudentsSmart(c)
@@ -152,20 +220,9 @@ func (a *ApplicationServer) ListStudentsSmart(c *fiber.Ctx) error {
 	offset := req.Filter.GetOffset()
 	limit := req.Filter.GetLimit()
 
-	q := a.db.
-		Select(`
-			id_pd AS id,
-		 	nm_pd AS nama_mahasiswa,
-			jk AS jenis_kelamin,
-			nik,
-			email,
-			telepon_seluler AS handphone,
-			telepon_rumah AS telepon`).
-		Table("mahasiswa").
-		Where("nik IS NOT NULL AND nik != '' AND LENGTH(nik) = 16")
-
-	if req.Filter.HasKeyword() {
-		q = q.Where("nama_mahasiswa LIKE ? OR nik LIKE ?", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	q, syncInfo, err := a.listStudentsQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
 	}
 
 	// Count total data without offset and limit
@@ -186,6 +243,10 @@ func (a *ApplicationServer) ListStudentsSmart(c *fiber.Ctx) error {
 		return HandleError(c, err)
 	}
 
+	if format := NegotiateTabularFormat(c); format != "" {
+		return WriteTabular(c, format, studentsTable, listStudents, pageInfo)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[ListStudentsResponse]]{
 		Code:    fiber.StatusOK,
 		Status:  http.StatusText(fiber.StatusOK),
@@ -194,10 +255,75 @@ func (a *ApplicationServer) ListStudentsSmart(c *fiber.Ctx) error {
 		Data: ListDataApiResponseWrapper[ListStudentsResponse]{
 			List:     listStudents,
 			PageInfo: pageInfo,
+			Sync:     syncInfo,
 		},
 	})
 }
 
+// listStudentsQuerySmart membangun query list mahasiswa (filter dan sync) tanpa paginasi
+func (a *ApplicationServer) listStudentsQuerySmart(req *ListStudentsRequest) (*gorm.DB, *gl.SyncInfo, error) {
+	q := a.studentsQuerySmart()
+
+	if req.Filter.HasKeyword() {
+		q = q.Where("nama_mahasiswa LIKE ? OR nik LIKE ?", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	}
+
+	// tabel mahasiswa Smart tidak memiliki timestamp, selalu snapshot penuh
+	q, syncInfo, err := a.ApplySyncFilter(q, req.SyncFilter, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return q, syncInfo, nil
+}
+
+// studentsQuerySmart adalah query dasar mahasiswa yang dipakai oleh list dan detail
+func (a *ApplicationServer) studentsQuerySmart() *gorm.DB {
+	return a.db.
+		Select(`
+			id_pd AS id,
+		 	nm_pd AS nama_mahasiswa,
+			jk AS jenis_kelamin,
+			nik,
+			email,
+			telepon_seluler AS handphone,
+			telepon_rumah AS telepon`).
+		Table("mahasiswa").
+		Where("nik IS NOT NULL AND nik != '' AND LENGTH(nik) = 16")
+}
+
+func (a *ApplicationServer) GetStudentSmart(c *fiber.Ctx) error {
+	student, err := findOne[ListStudentsResponse](a.studentsQuerySmart().Where("id_pd = ?", c.Params("id")), "Mahasiswa tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListStudentsResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data mahasiswa",
+		Data:    student,
+	})
+}
+
+func (a *ApplicationServer) GetStudentByNIKSmart(c *fiber.Ctx) error {
+	q := a.studentsQuerySmart().Where("nik = ?", c.Params("nik")).Order("id_pd ASC")
+
+	student, err := findUnique(q, "Mahasiswa tidak ditemukan", studentID)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListStudentsResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data mahasiswa",
+		Data:    student,
+	})
+}
+
 func (a *ApplicationServer) GetTotalStudentsSmart(c *fiber.Ctx) error {
 	var total int64
 
@@ -221,3 +347,51 @@ func (a *ApplicationServer) GetTotalStudentsSmart(c *fiber.Ctx) error {
 		},
 	})
 }
+
+func (a *ApplicationServer) ExportStudentsMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.ExportStudentsSmart(c)
+	}
+
+	req := NewListStudentsRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listStudentsQueryMisca(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, exportIdentity[ListStudentsResponse])
+}
+
+func (a *ApplicationServer) ExportStudentsSmart(c *fiber.Ctx) error {
+	req := NewListStudentsRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	q, syncInfo, err := a.listStudentsQuerySmart(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return StreamNDJSON(c, q, syncInfo, exportIdentity[ListStudentsResponse])
+}
+
+var studentsTable = TabularTable[ListStudentsResponse]{
+	Name: "mahasiswa",
+	Columns: []TabularColumn{
+		{HeaderID: "ID", HeaderEN: "ID"},
+		{HeaderID: "Nama", HeaderEN: "Name"},
+		{HeaderID: "Jenis Kelamin", HeaderEN: "Gender"},
+		{HeaderID: "NIK", HeaderEN: "NIK"},
+		{HeaderID: "Email", HeaderEN: "Email"},
+		{HeaderID: "Handphone", HeaderEN: "Mobile Phone"},
+		{HeaderID: "Telepon", HeaderEN: "Telephone"},
+	},
+	Rows: func(s ListStudentsResponse) [][]string {
+		return [][]string{{s.ID, s.Name, s.Gender, s.NIK, s.Email, s.Handphone, s.Telephone}}
+	},
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	studentStatusAktif  = "aktif"
+	studentStatusKeluar = "keluar"
+)
+
+type (
+	// StudentHistoryResponse berisi data registrasi mahasiswa dan ringkasan kelas yang diambil per semester.
+	StudentHistoryResponse struct {
+		ID         string                   `json:"id"`
+		Registrasi []StudentRegistration    `json:"registrasi"`
+		Semester   []StudentSemesterSummary `json:"semester"`
+	}
+
+	StudentRegistration struct {
+		IDPesertaDidik string     `json:"id_pd" gorm:"column:id_pd"`
+		NIPD           *string    `json:"nipd" gorm:"column:nipd"`
+		IDSMS          *string    `json:"id_sms" gorm:"column:id_sms"`
+		Prodi          *string    `json:"prodi" gorm:"column:prodi"`
+		Angkatan       *string    `json:"angkatan" gorm:"column:angkatan"`
+		IDJenisDaftar  *string    `json:"id_jns_daftar" gorm:"column:id_jns_daftar"`
+		IDJenisKeluar  *string    `json:"id_jns_keluar" gorm:"column:id_jns_keluar"`
+		Status         string     `json:"status" gorm:"-"`
+		TanggalMasuk   *time.Time `json:"tgl_masuk" gorm:"column:tgl_masuk"`
+		TanggalKeluar  *time.Time `json:"tgl_keluar" gorm:"column:tgl_keluar"`
+	}
+
+	StudentSemesterSummary struct {
+		IDPesertaDidik string  `json:"id_pd" gorm:"column:id_pd"`
+		Semester       string  `json:"semester" gorm:"column:semester"`
+		JumlahKelas    int     `json:"jumlah_kelas" gorm:"column:jumlah_kelas"`
+		SKS            float64 `json:"sks" gorm:"column:sks"`
+	}
+)
+
+// GetStudentHistoryMisca: satu mahasiswa (mahasiswa.id) bisa memiliki beberapa registrasi
+// pada mahasiswa_histori, misalnya pindah prodi atau melanjutkan jenjang.
+func (a *ApplicationServer) GetStudentHistoryMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetStudentHistorySmart(c)
+	}
+
+	id := c.Params("id")
+
+	var exists int64
+	if err := a.db.Table("mahasiswa").Where("id = ? AND deleted_at IS NULL", id).Count(&exists).Error; err != nil {
+		return HandleError(c, err)
+	}
+	if exists == 0 {
+		return HandleError(c, NewNotFoundError("Mahasiswa tidak ditemukan"))
+	}
+
+	registrations := make([]StudentRegistration, 0)
+	err := a.db.Table("mahasiswa_histori").
+		Select(`
+			mahasiswa_histori.id_pd AS id_pd,
+			mahasiswa_histori.nipd AS nipd,
+			mahasiswa_histori.id_sms AS id_sms,
+			sms.nm_lemb AS prodi,
+			mahasiswa_histori.angkatan AS angkatan,
+			mahasiswa_histori.id_jns_daftar AS id_jns_daftar,
+			mahasiswa_histori.id_jns_keluar AS id_jns_keluar,
+			mahasiswa_histori.tgl_masuk_sp AS tgl_masuk,
+			mahasiswa_histori.tgl_keluar AS tgl_keluar
+		`).
+		Joins("LEFT JOIN sms ON sms.id_sms = mahasiswa_histori.id_sms").
+		Where("mahasiswa_histori.id_mahasiswa = ?", id).
+		Order("mahasiswa_histori.tgl_masuk_sp ASC, mahasiswa_histori.id_pd ASC").
+		Scan(&registrations).Error
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	summaries := make([]StudentSemesterSummary, 0)
+	err = a.db.Table("nilai").
+		Select(`
+			nilai.id_pd AS id_pd,
+			nilai.smt_ambil AS semester,
+			COUNT(DISTINCT nilai.id_kls) AS jumlah_kelas,
+			SUM(COALESCE(matakuliah.sks_tm, 0) + COALESCE(matakuliah.sks_prak, 0) + COALESCE(matakuliah.sks_prak_lap, 0)) AS sks
+		`).
+		Joins("JOIN mahasiswa_histori ON mahasiswa_histori.id_pd = nilai.id_pd").
+		Joins("JOIN kelaskuliah ON kelaskuliah.id_kls = nilai.id_kls").
+		Joins("LEFT JOIN matakuliah_kurikulum ON matakuliah_kurikulum.id_mk_kur = kelaskuliah.id_mk_kur").
+		Joins("LEFT JOIN matakuliah ON matakuliah.id_mk = matakuliah_kurikulum.id_mk").
+		Where("mahasiswa_histori.id_mahasiswa = ?", id).
+		Group("nilai.id_pd, nilai.smt_ambil").
+		Order("nilai.smt_ambil ASC, nilai.id_pd ASC").
+		Scan(&summaries).Error
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return a.studentHistoryResponse(c, id, registrations, summaries)
+}
+
+// GetStudentHistorySmart: pada skema Smart baris mahasiswa sudah per registrasi (id_pd sama dengan
+// nilai.id_reg_pd), sehingga registrasi selalu berisi satu data dan angkatan diambil dari mulai_smt.
+func (a *ApplicationServer) GetStudentHistorySmart(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	registrations := make([]StudentRegistration, 0)
+	err := a.db.Table("mahasiswa").
+		Select(`
+			mahasiswa.id_pd AS id_pd,
+			mahasiswa.nipd AS nipd,
+			mahasiswa.id_sms AS id_sms,
+			sms.nm_lemb AS prodi,
+			LEFT(mahasiswa.mulai_smt, 4) AS angkatan,
+			mahasiswa.id_jns_daftar AS id_jns_daftar,
+			mahasiswa.id_jns_keluar AS id_jns_keluar,
+			mahasiswa.tgl_masuk_sp AS tgl_masuk,
+			mahasiswa.tgl_keluar AS tgl_keluar
+		`).
+		Joins("LEFT JOIN sms ON sms.id_sms = mahasiswa.id_sms").
+		Where("mahasiswa.id_pd = ?", id).
+		Scan(&registrations).Error
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	if len(registrations) == 0 {
+		return HandleError(c, NewNotFoundError("Mahasiswa tidak ditemukan"))
+	}
+
+	summaries := make([]StudentSemesterSummary, 0)
+	err = a.db.Table("nilai").
+		Select(`
+			nilai.id_reg_pd AS id_pd,
+			kelas_kuliah.id_smt AS semester,
+			COUNT(DISTINCT nilai.id_kls) AS jumlah_kelas,
+			SUM(COALESCE(matkul.sks_tm, 0) + COALESCE(matkul.sks_prak, 0) + COALESCE(matkul.sks_prak_lap, 0)) AS sks
+		`).
+		Joins("JOIN kelas_kuliah ON kelas_kuliah.id_kls = nilai.id_kls").
+		Joins("LEFT JOIN matkul ON matkul.id_mk = kelas_kuliah.id_mk").
+		Where("nilai.id_reg_pd = ?", id).
+		Group("nilai.id_reg_pd, kelas_kuliah.id_smt").
+		Order("kelas_kuliah.id_smt ASC").
+		Scan(&summaries).Error
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return a.studentHistoryResponse(c, id, registrations, summaries)
+}
+
+func (a *ApplicationServer) studentHistoryResponse(c *fiber.Ctx, id string, registrations []StudentRegistration, summaries []StudentSemesterSummary) error {
+	for i := range registrations {
+		// id_jns_keluar kosong berarti mahasiswa belum lulus atau keluar
+		registrations[i].Status = studentStatusAktif
+		if registrations[i].IDJenisKeluar != nil && *registrations[i].IDJenisKeluar != "" {
+			registrations[i].Status = studentStatusKeluar
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[StudentHistoryResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan riwayat mahasiswa",
+		Data: StudentHistoryResponse{
+			ID:         id,
+			Registrasi: registrations,
+			Semester:   summaries,
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+// Kondisi updated_since per resource dan per instansi. Setiap kondisi berisi tepat satu
+// placeholder (?) yang akan diisi dengan nilai updated_since, lalu digabung dengan OR.
+//
+// Perubahan pada tabel yang di-join (jadwal, ruangan, jadwal_perkuliahan, nilai) dicek lewat
+// subquery terhadap primary key resource, supaya baris lain dalam GROUP_CONCAT tidak ikut terfilter.
+//
+// Fallback: tabel yang tidak memiliki kolom timestamp (semester di kedua skema, dan seluruh
+// tabel Smart yang berasal dari skema feeder) tidak bisa di-delta, sehingga endpoint tetap
+// mengembalikan snapshot penuh dengan sync.full_snapshot = true.
+var (
+	studentsSyncConditionsMisca = []string{
+		"mahasiswa.updated_at >= ?",
+	}
+
+	lecturersSyncConditionsMisca = []string{
+		"dosen.updated_at >= ?",
+	}
+
+	kelasSyncConditionsMisca = []string{
+		"kelaskuliah.updated_at >= ?",
+		"kelaskuliah.id_kls IN (SELECT jadwal.id_kls FROM jadwal WHERE jadwal.updated_at >= ?)",
+		"kelaskuliah.id_kls IN (SELECT jadwal.id_kls FROM jadwal JOIN ruangan ON ruangan.id_ruangan = jadwal.id_ruangan WHERE ruangan.updated_at >= ?)",
+		"kelaskuliah.id_kls IN (SELECT jadwal_perkuliahan.id_kls FROM jadwal_perkuliahan WHERE jadwal_perkuliahan.updated_at >= ?)",
+	}
+
+	simpleStudentKelasSyncConditionsMisca = []string{
+		"mahasiswa.updated_at >= ?",
+		"nilai.id_pd IN (SELECT n.id_pd FROM nilai n WHERE n.updated_at >= ?)",
+	}
+
+	studentKelasDetailsSyncConditionsMisca = []string{
+		"mahasiswa.updated_at >= ?",
+		"nilai.id_pd IN (SELECT n.id_pd FROM nilai n WHERE n.updated_at >= ?)",
+		"nilai.id_pd IN (SELECT n.id_pd FROM nilai n JOIN kelaskuliah k ON k.id_kls = n.id_kls WHERE k.updated_at >= ?)",
+		"nilai.id_pd IN (SELECT n.id_pd FROM nilai n JOIN jadwal j ON j.id_kls = n.id_kls WHERE j.updated_at >= ?)",
+	}
+
+	roomsSyncConditionsMisca = []string{
+		"ruangan.updated_at >= ?",
+	}
+
+	smsSyncConditionsMisca = []string{
+		"sms.updated_at >= ?",
+	}
+)
+
+// ListSyncRequest dipakai oleh endpoint list yang tidak memiliki paginasi.
+type ListSyncRequest struct {
+	gl.SyncFilter
+}
+
+// ApplySyncFilter menambahkan filter updated_since ke query dan mengembalikan informasi sinkronisasi.
+// sync_token hanya diisi jika client mengirim updated_since atau sync=true. Jika conditions kosong, tabel sumber dianggap tidak memiliki timestamp dan query tidak difilter.
+func (a *ApplicationServer) ApplySyncFilter(q *gorm.DB, filter gl.SyncFilter, conditions []string) (*gorm.DB, *gl.SyncInfo, error) {
+	// ambil waktu dari database supaya tidak terpengaruh perbedaan jam server aplikasi, hanya jika
+	// sync_token diminta supaya list biasa tidak menambah satu round-trip
+	var syncedAt time.Time
+	if filter.WantsSyncToken() {
+		if err := a.db.Raw("SELECT NOW()").Scan(&syncedAt).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !filter.HasUpdatedSince() {
+		return q, gl.NewSyncInfo(nil, syncedAt, true), nil
+	}
+
+	since, err := filter.GetUpdatedSince()
+	if err != nil {
+		return nil, nil, NewBadRequestError(err)
+	}
+
+	if len(conditions) == 0 {
+		return q, gl.NewSyncInfo(&since, syncedAt, true), nil
+	}
+
+	args := make([]interface{}, len(conditions))
+	for i := range conditions {
+		args[i] = since
+	}
+
+	q = q.Where("("+strings.Join(conditions, " OR ")+")", args...)
+
+	return q, gl.NewSyncInfo(&since, syncedAt, false), nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+const (
+	tabularFormatCSV  = "csv"
+	tabularFormatXLSX = "xlsx"
+
+	csvContentType  = "text/csv"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	totalCountHeaderKey = "X-Total-Count"
+	currentPageQueryKey = "current_page"
+)
+
+type (
+	// TabularColumn adalah satu kolom spreadsheet dengan header dalam dua bahasa.
+	TabularColumn struct {
+		HeaderID string
+		HeaderEN string
+	}
+
+	// TabularTable menjelaskan cara meratakan satu item response menjadi baris spreadsheet.
+	// Satu item bisa menghasilkan beberapa baris, misalnya satu kelas dengan beberapa jadwal perkuliahan.
+	TabularTable[T any] struct {
+		Name    string
+		Columns []TabularColumn
+		Rows    func(item T) [][]string
+	}
+)
+
+// NegotiateTabularFormat menentukan format output dari query ?format= atau header Accept.
+// Mengembalikan string kosong jika client meminta JSON (default).
+func NegotiateTabularFormat(c *fiber.Ctx) string {
+	switch strings.ToLower(c.Query("format")) {
+	case tabularFormatCSV:
+		return tabularFormatCSV
+	case tabularFormatXLSX:
+		return tabularFormatXLSX
+	}
+
+	// hanya dicek jika Accept menyebut mime type secara eksplisit, */* tetap JSON
+	accept := c.Get(fiber.HeaderAccept)
+	switch {
+	case strings.Contains(accept, xlsxContentType):
+		return tabularFormatXLSX
+	case strings.Contains(accept, csvContentType):
+		return tabularFormatCSV
+	}
+
+	return ""
+}
+
+// RequestLang membaca bahasa dari ?lang=id|en (default id), dipakai untuk header spreadsheet dan nama hari.
+func RequestLang(c *fiber.Ctx) string {
+	if strings.ToLower(c.Query("lang")) == gl.LangEN {
+		return gl.LangEN
+	}
+	return gl.LangID
+}
+
+// WriteTabular menulis list sebagai CSV atau XLSX. Bahasa header diatur dengan ?lang=id|en (default id).
+// Untuk list yang dipaginasi, file hanya berisi halaman saat ini sehingga total data dan link halaman lain
+// dikirim lewat header X-Total-Count dan Link. pageInfo nil berarti list tidak dipaginasi.
+func WriteTabular[T any](c *fiber.Ctx, format string, table TabularTable[T], list []T, pageInfo *gl.PageInfo) error {
+	lang := RequestLang(c)
+	headers := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		headers[i] = column.HeaderID
+		if lang == gl.LangEN {
+			headers[i] = column.HeaderEN
+		}
+	}
+
+	rows := make([][]string, 0, len(list))
+	for _, item := range list {
+		rows = append(rows, table.Rows(item)...)
+	}
+
+	var buf bytes.Buffer
+	var contentType string
+
+	switch format {
+	case tabularFormatXLSX:
+		if err := writeXLSX(&buf, table.Name, headers, rows); err != nil {
+			return HandleError(c, err)
+		}
+		contentType = xlsxContentType
+	default:
+		if err := writeCSV(&buf, headers, rows); err != nil {
+			return HandleError(c, err)
+		}
+		contentType = csvContentType + "; charset=utf-8"
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, table.Name, format))
+	if pageInfo != nil {
+		setPaginationHeaders(c, pageInfo)
+	}
+
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+// setPaginationHeaders mengirim X-Total-Count dan Link (RFC 8288) ke halaman first, prev, next, dan last.
+func setPaginationHeaders(c *fiber.Ctx, pageInfo *gl.PageInfo) {
+	c.Set(totalCountHeaderKey, strconv.FormatInt(pageInfo.TotalData, 10))
+
+	u, err := url.Parse(c.OriginalURL())
+	if err != nil {
+		return
+	}
+
+	link := func(page int64, rel string) string {
+		query := u.Query()
+		query.Set(currentPageQueryKey, strconv.FormatInt(page, 10))
+		u.RawQuery = query.Encode()
+		return "<" + u.String() + `>; rel="` + rel + `"`
+	}
+
+	links := make([]string, 0, 4)
+	if pageInfo.LastPage > 0 {
+		links = append(links, link(1, "first"))
+	}
+	if pageInfo.HasPreviousPage {
+		links = append(links, link(pageInfo.CurrentPage-1, "prev"))
+	}
+	if pageInfo.HasNextPage {
+		links = append(links, link(pageInfo.CurrentPage+1, "next"))
+	}
+	if pageInfo.LastPage > 0 {
+		links = append(links, link(pageInfo.LastPage, "last"))
+	}
+
+	if len(links) > 0 {
+		c.Append(fiber.HeaderLink, strings.Join(links, ", "))
+	}
+}
+
+// numericLike cocok dengan angka negatif dan nomor telepon seperti "-2.5" atau "+62 812-3456-7890",
+// yang diawali + atau - tetapi tidak bisa menjadi formula karena tidak memuat operator lain selain -.
+var numericLike = regexp.MustCompile(`^[+-][0-9][0-9 ().-]*$`)
+
+// escapeFormula mencegah formula injection (CSV injection): sel yang diawali =, +, -, @, tab, atau CR
+// dianggap formula oleh aplikasi spreadsheet saat CSV dibuka, sehingga diberi prefix ' agar dibaca sebagai teks.
+// Nilai yang hanya berupa angka atau nomor telepon dibiarkan. Hanya dipakai untuk CSV, sel XLSX disimpan
+// sebagai string sehingga tidak pernah dievaluasi sebagai formula.
+func escapeFormula(value string) string {
+	if value == "" || numericLike.MatchString(value) {
+		return value
+	}
+
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+
+	return value
+}
+
+func escapeFormulaRows(rows [][]string) [][]string {
+	escaped := make([][]string, len(rows))
+	for i, row := range rows {
+		escaped[i] = make([]string, len(row))
+		for j, value := range row {
+			escaped[i][j] = escapeFormula(value)
+		}
+	}
+	return escaped
+}
+
+func writeCSV(buf *bytes.Buffer, headers []string, rows [][]string) error {
+	w := csv.NewWriter(buf)
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+
+	if err := w.WriteAll(escapeFormulaRows(rows)); err != nil {
+		return err
+	}
+
+	return w.Error()
+}
+
+func writeXLSX(buf *bytes.Buffer, sheet string, headers []string, rows [][]string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	for i, row := range append([][]string{headers}, rows...) {
+		cells := make([]interface{}, len(row))
+		for j, value := range row {
+			cells[j] = value
+		}
+
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+
+		if err := sw.SetRow(cell, cells); err != nil {
+			return err
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+
+	return f.Write(buf)
+}
+
+func tabularTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func tabularString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func tabularInt(i *int64) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.FormatInt(*i, 10)
+}
+
+func tabularBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+type (
+	TeachingLoadRequest struct {
+		Semester string `json:"semester" form:"semester" query:"semester"`
+	}
+
+	// TeachingLoadResponse adalah beban mengajar satu dosen dalam satu semester.
+	TeachingLoadResponse struct {
+		IDPTK    string            `json:"id_ptk"`
+		Semester string            `json:"semester"`
+		Total    TeachingLoadTotal `json:"total"`
+		Kelas    []TeachingClass   `json:"kelas"`
+	}
+
+	TeachingLoadTotal struct {
+		Kelas            int     `json:"kelas"`
+		SKS              float64 `json:"sks"`
+		RencanaPertemuan int     `json:"rencana_pertemuan"`
+	}
+
+	TeachingClass struct {
+		IDKelas          string          `json:"id_kelas" gorm:"column:id_kelas"`
+		IDSMS            string          `json:"id_sms" gorm:"column:id_sms"`
+		NamaKelas        string          `json:"nama_kelas" gorm:"column:nama_kelas"`
+		NamaMataKuliah   string          `json:"nama_matakuliah" gorm:"column:nama_matakuliah"`
+		KodeMataKuliah   string          `json:"kode_matakuliah" gorm:"column:kode_matakuliah"`
+		SKS              float64         `json:"sks" gorm:"column:sks"`
+		RencanaPertemuan *int            `json:"rencana_pertemuan" gorm:"column:rencana_pertemuan"`
+		Jadwal           []ClassSchedule `json:"jadwal" gorm:"-"`
+	}
+
+	// ClassSchedule adalah satu baris tabel jadwal (jadwal mingguan kelas).
+	ClassSchedule struct {
+		IDKelas     string  `json:"-" gorm:"column:id_kls"`
+		Hari        int     `json:"hari" gorm:"column:hari"`
+		NamaHari    string  `json:"nama_hari" gorm:"-"`
+		JamMulai    string  `json:"jam_mulai" gorm:"column:jam_mulai"`
+		JamSelesai  string  `json:"jam_selesai" gorm:"column:jam_selesai"`
+		IDRuangan   *string `json:"id_ruangan" gorm:"column:id_ruangan"`
+		NamaRuangan *string `json:"nama_ruangan" gorm:"column:nama_ruangan"`
+		KodeRuangan *string `json:"-" gorm:"column:kode_ruangan"` // hanya dipakai oleh /api/v2
+	}
+
+	// teachingLoadSource membedakan tabel dan kolom beban mengajar pada skema Misca dan Smart.
+	teachingLoadSource struct {
+		activeSemester func() (string, error)
+		// tabel dan kolom untuk memastikan :id ada, memakai id yang sama dengan lecturerColumn
+		lecturerTable    string
+		lecturerIDColumn string
+		lecturerColumn   string
+		semesterColumn   string
+		roomNameColumn   string
+		classes          *gorm.DB
+	}
+)
+
+func (a *ApplicationServer) GetLecturerTeachingLoadMisca(c *fiber.Ctx) error {
+	if IsSmartInstansi(c) {
+		return a.GetLecturerTeachingLoadSmart(c)
+	}
+
+	q := a.db.Table("akt_mengajar_dosen").
+		Select(`
+			kelaskuliah.id_kls AS id_kelas,
+			kelaskuliah.id_sms AS id_sms,
+			kelaskuliah.nm_kls AS nama_kelas,
+			matakuliah.nm_mk AS nama_matakuliah,
+			matakuliah.kode_mk AS kode_matakuliah,
+			COALESCE(matakuliah.sks_tm, 0) + COALESCE(matakuliah.sks_prak, 0) + COALESCE(matakuliah.sks_prak_lap, 0) AS sks,
+			akt_mengajar_dosen.temu_rencana AS rencana_pertemuan
+		`).
+		Joins("JOIN kelaskuliah ON kelaskuliah.id_kls = akt_mengajar_dosen.id_kls").
+		Joins("LEFT JOIN matakuliah_kurikulum ON matakuliah_kurikulum.id_mk_kur = kelaskuliah.id_mk_kur").
+		Joins("LEFT JOIN matakuliah ON matakuliah.id_mk = matakuliah_kurikulum.id_mk").
+		Order("kelaskuliah.id_kls ASC")
+
+	return a.getLecturerTeachingLoad(c, teachingLoadSource{
+		activeSemester:   a.getActiveSemesterIDMisca,
+		lecturerTable:    "dosen",
+		lecturerIDColumn: "id_ptk",
+		lecturerColumn:   "akt_mengajar_dosen.id_ptk",
+		semesterColumn:   "kelaskuliah.id_smt",
+		roomNameColumn:   "ruangan.nama_ruangan",
+		classes:          q,
+	})
+}
+
+func (a *ApplicationServer) GetLecturerTeachingLoadSmart(c *fiber.Ctx) error {
+	q := a.db.Table("akt_ajar_dosen").
+		Select(`
+			kelas_kuliah.id_kls AS id_kelas,
+			kelas_kuliah.id_sms AS id_sms,
+			kelas_kuliah.nm_kls AS nama_kelas,
+			matkul.nm_mk AS nama_matakuliah,
+			matkul.kode_mk AS kode_matakuliah,
+			COALESCE(matkul.sks_tm, 0) + COALESCE(matkul.sks_prak, 0) + COALESCE(matkul.sks_prak_lap, 0) AS sks,
+			akt_ajar_dosen.jml_tm_renc AS rencana_pertemuan
+		`).
+		Joins("JOIN kelas_kuliah ON kelas_kuliah.id_kls = akt_ajar_dosen.id_kls").
+		Joins("LEFT JOIN matkul ON matkul.id_mk = kelas_kuliah.id_mk").
+		Order("kelas_kuliah.id_kls ASC")
+
+	// :id adalah id_reg_ptk (registrasi dosen), bukan dosen.id_ptk, sehingga dosen dicari pada akt_ajar_dosen
+	return a.getLecturerTeachingLoad(c, teachingLoadSource{
+		activeSemester:   a.getActiveSemesterIDSmart,
+		lecturerTable:    "akt_ajar_dosen",
+		lecturerIDColumn: "id_reg_ptk",
+		lecturerColumn:   "akt_ajar_dosen.id_reg_ptk",
+		semesterColumn:   "kelas_kuliah.id_smt",
+		roomNameColumn:   "ruangan.kode_ruangan",
+		classes:          q,
+	})
+}
+
+func (a *ApplicationServer) getLecturerTeachingLoad(c *fiber.Ctx, src teachingLoadSource) error {
+	req := new(TeachingLoadRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	idPTK := c.Params("id")
+
+	var exists int64
+	if err := a.db.Table(src.lecturerTable).Where(src.lecturerIDColumn+" = ?", idPTK).Count(&exists).Error; err != nil {
+		return HandleError(c, err)
+	}
+	if exists == 0 {
+		return HandleError(c, NewNotFoundError("Dosen tidak ditemukan"))
+	}
+
+	if req.Semester == "" {
+		activeSemester, err := src.activeSemester()
+		if err != nil {
+			return HandleError(c, err)
+		}
+		req.Semester = activeSemester
+	}
+
+	classes := make([]TeachingClass, 0)
+	err := src.classes.
+		Where(src.lecturerColumn+" = ?", idPTK).
+		Where(src.semesterColumn+" = ?", req.Semester).
+		Scan(&classes).Error
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	kelasIDs := make([]string, 0, len(classes))
+	for _, kelas := range classes {
+		kelasIDs = append(kelasIDs, kelas.IDKelas)
+	}
+
+	schedules, err := a.findClassSchedules(kelasIDs, src.roomNameColumn)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	response := TeachingLoadResponse{
+		IDPTK:    idPTK,
+		Semester: req.Semester,
+	}
+
+	for i := range classes {
+		classes[i].Jadwal = schedules[classes[i].IDKelas]
+		if classes[i].Jadwal == nil {
+			classes[i].Jadwal = []ClassSchedule{}
+		}
+
+		response.Total.Kelas++
+		response.Total.SKS += classes[i].SKS
+		if classes[i].RencanaPertemuan != nil {
+			response.Total.RencanaPertemuan += *classes[i].RencanaPertemuan
+		}
+	}
+	response.Kelas = classes
+
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[TeachingLoadResponse]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: "Sukses mendapatkan data beban mengajar dosen",
+		Data:    response,
+	})
+}
+
+// findClassSchedules mengambil jadwal mingguan beberapa kelas dalam satu query, dikelompokkan per id kelas.
+// roomNameColumn adalah kolom nama ruangan (ruangan.nama_ruangan pada Misca, ruangan.kode_ruangan pada Smart).
+func (a *ApplicationServer) findClassSchedules(kelasIDs []string, roomNameColumn string) (map[string][]ClassSchedule, error) {
+	result := make(map[string][]ClassSchedule, len(kelasIDs))
+	if len(kelasIDs) == 0 {
+		return result, nil
+	}
+
+	rows := make([]ClassSchedule, 0)
+	err := a.db.Table("jadwal").
+		Select(`
+			CAST(jadwal.id_kls AS CHAR) AS id_kls,
+			jadwal.hari AS hari,
+			jadwal.jam_mulai AS jam_mulai,
+			jadwal.jam_selesai AS jam_selesai,
+			CAST(jadwal.id_ruangan AS CHAR) AS id_ruangan,
+			`+roomNameColumn+` AS nama_ruangan,
+			ruangan.kode_ruangan AS kode_ruangan
+		`).
+		Joins("LEFT JOIN ruangan ON ruangan.id_ruangan = jadwal.id_ruangan").
+		Where("jadwal.id_kls IN ?", kelasIDs).
+		Order("jadwal.id_kls ASC, jadwal.hari ASC, jadwal.jam_mulai ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		row.NamaHari = gl.NamaHari(row.Hari)
+		result[row.IDKelas] = append(result[row.IDKelas], row)
+	}
+
+	return result, nil
+}
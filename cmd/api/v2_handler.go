@@ -0,0 +1,502 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+// Endpoint /api/v2 memakai satu skema per resource untuk Misca dan Smart dengan nama field bahasa Inggris.
+// Query, filter, dan sync tetap memakai query v1, hanya bentuk response yang dinormalisasi di sini.
+type (
+	SemesterV2 struct {
+		ID           string     `json:"id"`
+		Name         string     `json:"name"`
+		Active       bool       `json:"active"`
+		AcademicYear string     `json:"academic_year"`
+		Year         *int       `json:"year"`
+		Term         *int       `json:"term"`
+		TermName     string     `json:"term_name"`
+		StartDate    *time.Time `json:"start_date"`
+		EndDate      *time.Time `json:"end_date"`
+		KrsStart     *time.Time `json:"krs_start"`
+		KrsEnd       *time.Time `json:"krs_end"`
+		GradingStart *time.Time `json:"grading_start"`
+		GradingEnd   *time.Time `json:"grading_end"`
+		Previous     *string    `json:"previous"`
+		Next         *string    `json:"next"`
+	}
+
+	// PersonV2 dipakai untuk mahasiswa dan dosen.
+	PersonV2 struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Gender      string `json:"gender"`
+		NIK         string `json:"nik"`
+		Email       string `json:"email"`
+		MobilePhone string `json:"mobile_phone"`
+		Phone       string `json:"phone"`
+	}
+
+	// RoomV2 memisahkan kode dan nama ruangan. Ruangan Smart hanya memiliki kode, sehingga name, type_id,
+	// dan capacity bernilai null.
+	RoomV2 struct {
+		ID              string     `json:"id"`
+		Code            string     `json:"code"`
+		Name            *string    `json:"name"`
+		TypeID          *string    `json:"type_id"`
+		Description     string     `json:"description"`
+		Capacity        *int       `json:"capacity"`
+		StudyProgramIDs []string   `json:"study_program_ids"`
+		CreatedAt       *time.Time `json:"created_at"`
+		UpdatedAt       *time.Time `json:"updated_at"`
+	}
+
+	// StudyProgramV2 adalah sms (fakultas atau prodi). Jendela KRS dan pengisian nilai hanya tersedia pada Misca.
+	StudyProgramV2 struct {
+		ID                 string     `json:"id"`
+		Name               string     `json:"name"`
+		NameEN             *string    `json:"name_en"`
+		Code               *string    `json:"code"`
+		ParentID           *string    `json:"parent_id"`
+		TypeID             *int64     `json:"type_id"`
+		EducationLevelID   *int64     `json:"education_level_id"`
+		EducationLevelName *string    `json:"education_level_name"`
+		KrsStart           *time.Time `json:"krs_start"`
+		KrsEnd             *time.Time `json:"krs_end"`
+		GradingStart       *time.Time `json:"grading_start"`
+		GradingEnd         *time.Time `json:"grading_end"`
+		CreatedAt          *time.Time `json:"created_at"`
+		UpdatedAt          *time.Time `json:"updated_at"`
+	}
+)
+
+var ErrInvalidSortField = errors.New("sort_by tidak tersedia")
+
+// sort_by v2 memakai nama field v2, dipetakan ke kolom yang dipakai query v1
+var (
+	studentSortColumnsV2      = map[string]string{"id": "id", "name": "nama_mahasiswa", "nik": "nik", "email": "email"}
+	lecturerSortColumnsV2     = map[string]string{"id": "id_ptk", "name": "nama_dosen", "nik": "nik", "email": "email"}
+	studyProgramSortColumnsV2 = map[string]string{"id": "sms.id_sms", "name": "nm_lemb", "code": "kode_sms"}
+)
+
+func (a *ApplicationServer) ListSemestersV2(c *fiber.Ctx) error {
+	req := new(ListSemestersRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := Validator.Struct(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	listQuery := a.listSemestersQueryMisca
+	if IsSmartInstansi(c) {
+		listQuery = a.listSemestersQuerySmart
+	}
+
+	q, syncInfo, err := listQuery(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	semesters := make([]ListSemestersResponse, 0)
+	if err := q.Order("semester.id_smt ASC").Find(&semesters).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeListV2(c, "Sukses mendapatkan semua data semester", newSemestersV2(semesters, RequestLang(c)), nil, syncInfo)
+}
+
+func (a *ApplicationServer) GetActiveSemesterV2(c *fiber.Ctx) error {
+	q := a.semestersQueryMisca().Where("setting.param = 'periode_berlaku'")
+	if IsSmartInstansi(c) {
+		q = a.semestersQuerySmart().Where("semester.a_periode_aktif = 1")
+	}
+
+	semester, err := findOne[ListSemestersResponse](q, "Semester aktif tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeItemV2(c, "Sukses mendapatkan data semester yang aktif", newSemestersV2([]ListSemestersResponse{semester}, RequestLang(c))[0])
+}
+
+func (a *ApplicationServer) GetSemesterV2(c *fiber.Ctx) error {
+	q := a.semestersQueryMisca()
+	if IsSmartInstansi(c) {
+		q = a.semestersQuerySmart()
+	}
+
+	semester, err := findOne[ListSemestersResponse](q.Where("semester.id_smt = ?", c.Params("id")), "Semester tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeItemV2(c, "Sukses mendapatkan data semester", newSemestersV2([]ListSemestersResponse{semester}, RequestLang(c))[0])
+}
+
+func (a *ApplicationServer) ListStudentsV2(c *fiber.Ctx) error {
+	req := NewListStudentsRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := sortV2(&req.Filter, studentSortColumnsV2); err != nil {
+		return HandleError(c, err)
+	}
+
+	listQuery := a.listStudentsQueryMisca
+	if IsSmartInstansi(c) {
+		listQuery = a.listStudentsQuerySmart
+	}
+
+	q, syncInfo, err := listQuery(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	students := make([]ListStudentsResponse, 0)
+	pageInfo, err := paginateV2(q, req.Filter, &students)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	persons := make([]PersonV2, 0, len(students))
+	for _, s := range students {
+		persons = append(persons, newStudentV2(s))
+	}
+
+	return writeListV2(c, "Sukses mendapatkan data mahasiswa", persons, pageInfo, syncInfo)
+}
+
+func (a *ApplicationServer) GetStudentV2(c *fiber.Ctx) error {
+	q := a.studentsQueryMisca().Where("id = ?", c.Params("id"))
+	if IsSmartInstansi(c) {
+		q = a.studentsQuerySmart().Where("id_pd = ?", c.Params("id"))
+	}
+
+	s, err := findOne[ListStudentsResponse](q, "Mahasiswa tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeItemV2(c, "Sukses mendapatkan data mahasiswa", newStudentV2(s))
+}
+
+func (a *ApplicationServer) ListLecturersV2(c *fiber.Ctx) error {
+	req := NewListLecturerRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := sortV2(&req.Filter, lecturerSortColumnsV2); err != nil {
+		return HandleError(c, err)
+	}
+
+	listQuery := a.listLecturerQueryMisca
+	if IsSmartInstansi(c) {
+		listQuery = a.listLecturerQuerySmart
+	}
+
+	q, syncInfo, err := listQuery(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	lecturers := make([]ListLecturerResponse, 0)
+	pageInfo, err := paginateV2(q, req.Filter, &lecturers)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	persons := make([]PersonV2, 0, len(lecturers))
+	for _, l := range lecturers {
+		persons = append(persons, newLecturerV2(l))
+	}
+
+	return writeListV2(c, "Sukses mendapatkan data dosen", persons, pageInfo, syncInfo)
+}
+
+func (a *ApplicationServer) GetLecturerV2(c *fiber.Ctx) error {
+	q := a.lecturersQueryMisca()
+	if IsSmartInstansi(c) {
+		q = a.lecturersQuerySmart()
+	}
+
+	l, err := findOne[ListLecturerResponse](q.Where("id_ptk = ?", c.Params("id")), "Dosen tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeItemV2(c, "Sukses mendapatkan data dosen", newLecturerV2(l))
+}
+
+func (a *ApplicationServer) ListRoomsV2(c *fiber.Ctx) error {
+	req := new(ListSyncRequest)
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	listQuery := a.listRoomsQueryMisca
+	if IsSmartInstansi(c) {
+		listQuery = a.listRoomsQuerySmart
+	}
+
+	q, syncInfo, err := listQuery(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	rooms := make([]Ruangan, 0)
+	if err := q.Order("id_ruangan ASC").Find(&rooms).Error; err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeListV2(c, "Sukses mendapatkan data ruangan", newRoomsV2(rooms, IsSmartInstansi(c)), nil, syncInfo)
+}
+
+func (a *ApplicationServer) GetRoomV2(c *fiber.Ctx) error {
+	q := a.db.Table("ruangan")
+	if IsSmartInstansi(c) {
+		q = a.roomsQuerySmart()
+	}
+
+	room, err := findOne[Ruangan](q.Where("id_ruangan = ?", c.Params("id")), "Ruangan tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeItemV2(c, "Sukses mendapatkan data ruangan", newRoomsV2([]Ruangan{room}, IsSmartInstansi(c))[0])
+}
+
+// ListStudyProgramsV2 mengembalikan sms sebagai list datar dengan paginasi, keyword (nama atau kode), dan sorting.
+func (a *ApplicationServer) ListStudyProgramsV2(c *fiber.Ctx) error {
+	req := NewListSMSRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := sortV2(&req.Filter, studyProgramSortColumnsV2); err != nil {
+		return HandleError(c, err)
+	}
+
+	listQuery, kodeColumn := a.listSMSQueryMisca, "sms.kode_sms"
+	if IsSmartInstansi(c) {
+		listQuery, kodeColumn = a.listSMSQuerySmart, "sms.kode_prodi"
+	}
+
+	q, syncInfo, err := listQuery(&ListSyncRequest{SyncFilter: req.SyncFilter})
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	if req.Filter.HasKeyword() {
+		q = q.Where("(sms.nm_lemb LIKE ? OR "+kodeColumn+" LIKE ?)", "%"+req.Filter.Keyword+"%", "%"+req.Filter.Keyword+"%")
+	}
+
+	if req.Filter.HasSort() {
+		q = q.Order(clause.OrderByColumn{
+			Column: clause.Column{Name: req.Filter.SortBy},
+			Desc:   req.Filter.IsDesc(),
+		})
+	} else {
+		q = q.Order("sms.id_sms ASC")
+	}
+
+	sms := make([]SMS, 0)
+	pageInfo, err := paginateV2(q, req.Filter, &sms)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeListV2(c, "Sukses mendapatkan data sms", newStudyProgramsV2(sms), pageInfo, syncInfo)
+}
+
+func (a *ApplicationServer) GetStudyProgramV2(c *fiber.Ctx) error {
+	q := a.smsQueryMisca()
+	if IsSmartInstansi(c) {
+		q = a.smsQuerySmart()
+	}
+
+	sms, err := findOne[SMS](q.Where("sms.id_sms = ?", c.Params("id")), "Program studi tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeItemV2(c, "Sukses mendapatkan data sms", newStudyProgramsV2([]SMS{sms})[0])
+}
+
+func newSemestersV2(semesters []ListSemestersResponse, lang string) []SemesterV2 {
+	setSemesterMetadata(semesters, lang)
+
+	response := make([]SemesterV2, 0, len(semesters))
+	for _, s := range semesters {
+		response = append(response, SemesterV2{
+			ID:           s.ID,
+			Name:         s.Name,
+			Active:       s.Active == 1,
+			AcademicYear: s.AcademicYear,
+			Year:         s.Year,
+			Term:         s.Term,
+			TermName:     s.TermName,
+			StartDate:    s.StartDate,
+			EndDate:      s.EndDate,
+			KrsStart:     s.KrsStart,
+			KrsEnd:       s.KrsEnd,
+			GradingStart: s.GradingStart,
+			GradingEnd:   s.GradingEnd,
+			Previous:     s.Previous,
+			Next:         s.Next,
+		})
+	}
+	return response
+}
+
+func newStudentV2(s ListStudentsResponse) PersonV2 {
+	return PersonV2{
+		ID:          s.ID,
+		Name:        s.Name,
+		Gender:      s.Gender,
+		NIK:         s.NIK,
+		Email:       s.Email,
+		MobilePhone: s.Handphone,
+		Phone:       s.Telephone,
+	}
+}
+
+func newLecturerV2(l ListLecturerResponse) PersonV2 {
+	return PersonV2{
+		ID:          l.ID,
+		Name:        l.Name,
+		Gender:      l.Gender,
+		NIK:         l.NIK,
+		Email:       l.Email,
+		MobilePhone: l.Handphone,
+		Phone:       l.Telephone,
+	}
+}
+
+func newRoomsV2(rooms []Ruangan, smart bool) []RoomV2 {
+	convert := convertRuanganMisca
+	if smart {
+		convert = convertRuanganSmart
+	}
+
+	// konversi v1 tidak pernah mengembalikan error
+	converted, _ := convert(rooms)
+
+	response := make([]RoomV2, 0, len(converted))
+	for _, r := range converted {
+		room := RoomV2{
+			ID:              r.IDRuangan,
+			Code:            r.KodeRuangan,
+			Description:     r.Keterangan,
+			StudyProgramIDs: r.IDSMS,
+		}
+		if room.StudyProgramIDs == nil {
+			room.StudyProgramIDs = []string{}
+		}
+
+		if !smart {
+			room.Name = &r.NamaRuangan
+			room.Capacity = &r.Kapasitas
+			if r.IDJenisRuangan != "" {
+				room.TypeID = &r.IDJenisRuangan
+			}
+			if !r.CreatedAt.IsZero() {
+				room.CreatedAt = &r.CreatedAt
+			}
+			if !r.UpdatedAt.IsZero() {
+				room.UpdatedAt = &r.UpdatedAt
+			}
+		}
+
+		response = append(response, room)
+	}
+	return response
+}
+
+func newStudyProgramsV2(sms []SMS) []StudyProgramV2 {
+	response := make([]StudyProgramV2, 0, len(sms))
+	for _, s := range sms {
+		response = append(response, StudyProgramV2{
+			ID:                 s.IDSms,
+			Name:               s.NmLemb,
+			NameEN:             s.NmLembInggris,
+			Code:               s.KodeSms,
+			ParentID:           s.IDIndukSms,
+			TypeID:             s.IDJenisSms,
+			EducationLevelID:   s.IDJenjangDidik,
+			EducationLevelName: s.NamaJenjangDidik,
+			KrsStart:           s.MulaiIsiKrs,
+			KrsEnd:             s.AkhirIsiKrs,
+			GradingStart:       s.MulaiIsiNilai,
+			GradingEnd:         s.AkhirIsiNilai,
+			CreatedAt:          s.CreatedAt,
+			UpdatedAt:          s.UpdatedAt,
+		})
+	}
+	return response
+}
+
+// paginateV2 menghitung total data q lalu mengambil satu halaman ke items.
+func paginateV2[M any](q *gorm.DB, filter gl.Filter, items *[]M) (*gl.PageInfo, error) {
+	offset := filter.GetOffset()
+	limit := filter.GetLimit()
+
+	var totalData int64
+	if err := q.Count(&totalData).Error; err != nil {
+		return nil, err
+	}
+
+	if err := q.Offset(int(offset)).Limit(int(limit)).Scan(items).Error; err != nil {
+		return nil, err
+	}
+
+	return gl.NewPageInfo(filter.CurrentPage, limit, offset, totalData)
+}
+
+// sortV2 mengganti sort_by (nama field v2) dengan kolom query v1, nama field lain ditolak dengan 400.
+func sortV2(filter *gl.Filter, columns map[string]string) error {
+	if !filter.HasSort() {
+		return nil
+	}
+
+	column, ok := columns[filter.SortBy]
+	if !ok || column == "" {
+		return NewBadRequestError(errors.Wrapf(ErrInvalidSortField, "sort_by %q", filter.SortBy))
+	}
+
+	filter.SortBy = column
+	return nil
+}
+
+func writeListV2[T any](c *fiber.Ctx, message string, list []T, pageInfo *gl.PageInfo, syncInfo *gl.SyncInfo) error {
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[ListDataApiResponseWrapper[T]]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: message,
+		Data: ListDataApiResponseWrapper[T]{
+			List:     list,
+			PageInfo: pageInfo,
+			Sync:     syncInfo,
+		},
+	})
+}
+
+func writeItemV2[T any](c *fiber.Ctx, message string, item T) error {
+	return c.Status(fiber.StatusOK).JSON(ApiResponse[T]{
+		Code:    fiber.StatusOK,
+		Status:  http.StatusText(fiber.StatusOK),
+		Success: true,
+		Message: message,
+		Data:    item,
+	})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	gl "lab.garudacyber.co.id/g-learning-connector"
+)
+
+type (
+	// ClassV2 tidak memuat string lama jadwal, nama_ruangan, dan total_pertemuan, jadwal lengkap ada di schedule
+	// dan sesi jadwal_perkuliahan (hanya Misca) ada di sessions.
+	ClassV2 struct {
+		ID             string            `json:"id"`
+		StudyProgramID string            `json:"study_program_id"`
+		Name           string            `json:"name"`
+		Semester       string            `json:"semester"`
+		Course         CourseV2          `json:"course"`
+		LecturerIDs    []string          `json:"lecturer_ids"`
+		Schedule       []ClassScheduleV2 `json:"schedule"`
+		Sessions       []ClassSessionV2  `json:"sessions"`
+	}
+
+	CourseV2 struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	}
+
+	ClassScheduleV2 struct {
+		DayOfWeek int        `json:"day_of_week"`
+		DayName   string     `json:"day_name"`
+		Start     string     `json:"start"`
+		End       string     `json:"end"`
+		Room      *RoomRefV2 `json:"room"`
+	}
+
+	// RoomRefV2 adalah ruangan pada jadwal, name bernilai null pada Smart seperti RoomV2.
+	RoomRefV2 struct {
+		ID   string  `json:"id"`
+		Code *string `json:"code"`
+		Name *string `json:"name"`
+	}
+
+	ClassSessionV2 struct {
+		ID     int64   `json:"id"`
+		Number int64   `json:"number"`
+		Date   string  `json:"date"`
+		Start  string  `json:"start"`
+		End    string  `json:"end"`
+		Method string  `json:"method"`
+		Type   string  `json:"type"`
+		Status string  `json:"status"`
+		RoomID *int64  `json:"room_id"`
+		URL    *string `json:"url"`
+	}
+
+	// classSourceV2 membedakan query kelas dan tabel dosen pengajar pada skema Misca dan Smart.
+	classSourceV2 struct {
+		list      func(req *ListStudentKelasRequest) (*gorm.DB, *gl.SyncInfo, error)
+		base      func() *gorm.DB
+		idColumn  string
+		ajarTable string
+		ptkColumn string
+		// roomNameColumn NULL pada Smart karena ruangan Smart hanya memiliki kode
+		roomNameColumn string
+		// jadwal_perkuliahan hanya tersedia pada Misca
+		sessions bool
+	}
+)
+
+var classSortColumnsV2 = map[string]string{
+	"id":          "id_kelas",
+	"name":        "nama_kelas",
+	"semester":    "semester",
+	"course_code": "kode_matakuliah",
+	"course_name": "nama_matakuliah",
+}
+
+func (a *ApplicationServer) classSourceV2(c *fiber.Ctx) classSourceV2 {
+	if IsSmartInstansi(c) {
+		return classSourceV2{
+			list:           a.listKelasQuerySmart,
+			base:           a.kelasQuerySmart,
+			idColumn:       "kelas_kuliah.id_kls",
+			ajarTable:      "akt_ajar_dosen",
+			ptkColumn:      "id_reg_ptk",
+			roomNameColumn: "NULL",
+		}
+	}
+
+	return classSourceV2{
+		list:           a.listKelasQueryMisca,
+		base:           a.kelasQueryMisca,
+		idColumn:       "kelaskuliah.id_kls",
+		ajarTable:      "akt_mengajar_dosen",
+		ptkColumn:      "id_ptk",
+		roomNameColumn: "ruangan.nama_ruangan",
+		sessions:       true,
+	}
+}
+
+// ListClassesV2 mengembalikan kelas pada ?semester= (default semester aktif) dengan paginasi.
+func (a *ApplicationServer) ListClassesV2(c *fiber.Ctx) error {
+	req := NewListKelasRequest()
+	if err := c.QueryParser(req); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := sortV2(&req.Filter, classSortColumnsV2); err != nil {
+		return HandleError(c, err)
+	}
+
+	src := a.classSourceV2(c)
+	q, syncInfo, err := src.list(req)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	listKelas := make([]ListKelasResponse, 0)
+	pageInfo, err := paginateV2(q, req.Filter, &listKelas)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	classes, err := a.newClassesV2(src, listKelas, RequestLang(c))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeListV2(c, "Sukses mendapatkan data kelas", classes, pageInfo, syncInfo)
+}
+
+func (a *ApplicationServer) GetClassV2(c *fiber.Ctx) error {
+	src := a.classSourceV2(c)
+
+	kelas, err := findOne[ListKelasResponse](src.base().Where(src.idColumn+" = ?", c.Params("id")), "Kelas tidak ditemukan")
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	classes, err := a.newClassesV2(src, []ListKelasResponse{kelas}, RequestLang(c))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return writeItemV2(c, "Sukses mendapatkan data kelas", classes[0])
+}
+
+// newClassesV2 melengkapi dosen pengajar, jadwal mingguan, dan sesi kelas lalu mengubahnya ke skema v2.
+func (a *ApplicationServer) newClassesV2(src classSourceV2, listKelas []ListKelasResponse, lang string) ([]ClassV2, error) {
+	splitDosenPengajar(listKelas)
+	if err := a.completeTruncatedKelas(listKelas, src.ajarTable, src.ptkColumn); err != nil {
+		return nil, err
+	}
+
+	if src.sessions {
+		a.attachJadwalPerkuliahan(listKelas)
+	}
+
+	kelasIDs := make([]string, 0, len(listKelas))
+	for _, kelas := range listKelas {
+		kelasIDs = append(kelasIDs, kelas.IDKelas)
+	}
+
+	schedules, err := a.findClassSchedules(kelasIDs, src.roomNameColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]ClassV2, 0, len(listKelas))
+	for _, kelas := range listKelas {
+		class := ClassV2{
+			ID:             kelas.IDKelas,
+			StudyProgramID: kelas.IDSMS,
+			Name:           kelas.NamaKelas,
+			Semester:       kelas.Semester,
+			Course:         CourseV2{Code: kelas.KodeMataKuliah, Name: kelas.NamaMataKuliah},
+			LecturerIDs:    kelas.IDDosenPengajar,
+			Schedule:       make([]ClassScheduleV2, 0),
+			Sessions:       make([]ClassSessionV2, 0, len(kelas.JadwalPerkuliahan)),
+		}
+		if class.LecturerIDs == nil {
+			class.LecturerIDs = []string{}
+		}
+
+		for _, row := range schedules[kelas.IDKelas] {
+			schedule := ClassScheduleV2{
+				DayOfWeek: row.Hari,
+				DayName:   gl.DayName(row.Hari, lang),
+				Start:     row.JamMulai,
+				End:       row.JamSelesai,
+			}
+			if row.IDRuangan != nil {
+				schedule.Room = &RoomRefV2{ID: *row.IDRuangan, Code: row.KodeRuangan, Name: row.NamaRuangan}
+			}
+			class.Schedule = append(class.Schedule, schedule)
+		}
+
+		for _, sesi := range kelas.JadwalPerkuliahan {
+			class.Sessions = append(class.Sessions, ClassSessionV2{
+				ID:     sesi.ID,
+				Number: sesi.Sesi,
+				Date:   sesi.Tanggal.Format(time.DateOnly),
+				Start:  sesi.JamMulai,
+				End:    sesi.JamSelesai,
+				Method: sesi.MetodePembelajaran,
+				Type:   sesi.JenisPertemuan,
+				Status: sesi.Status,
+				RoomID: sesi.IDRuangan,
+				URL:    sesi.URL,
+			})
+		}
+
+		classes = append(classes, class)
+	}
+
+	return classes, nil
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/spf13/viper"
 )
 
+// DefaultDeletionSnapshotInterval adalah jeda antar refresh snapshot penghapusan jika DELETION_SNAPSHOT_INTERVAL kosong.
+const DefaultDeletionSnapshotInterval = 5 * time.Minute
+
 type Config struct {
 	AppName string `mapstructure:"APP_NAME"`
 	AppEnv  string `mapstructure:"APP_ENV"`
@@ -21,6 +24,15 @@ type Config struct {
 	DBPoolIdle     int           `mapstructure:"DB_POOL_IDLE"`
 	DBPoolMax      int           `mapstructure:"DB_POOL_MAX"`
 	DBPoolLifetime time.Duration `mapstructure:"DB_POOL_LIFETIME"`
+
+	DBGroupConcatMaxLen int `mapstructure:"DB_GROUP_CONCAT_MAX_LEN"`
+
+	// interval refresh snapshot penghapusan untuk tabel yang melakukan hard delete, 0 berarti tidak di-refresh
+	DeletionSnapshotInterval time.Duration `mapstructure:"DELETION_SNAPSHOT_INTERVAL"`
+
+	// tanggal (YYYY-MM-DD) untuk header Deprecation dan Sunset pada /api/misca, kosong berarti header tidak dikirim
+	APIV1DeprecatedAt string `mapstructure:"API_V1_DEPRECATED_AT"`
+	APIV1SunsetAt     string `mapstructure:"API_V1_SUNSET_AT"`
 }
 
 func NewConfig() (*Config, error) {
@@ -32,6 +44,9 @@ func NewConfig() (*Config, error) {
 	// read from environment variables
 	viperConfig.AutomaticEnv()
 
+	viperConfig.SetDefault("DB_GROUP_CONCAT_MAX_LEN", DefaultGroupConcatMaxLen)
+	viperConfig.SetDefault("DELETION_SNAPSHOT_INTERVAL", DefaultDeletionSnapshotInterval)
+
 	err := viperConfig.ReadInConfig()
 	if err != nil {
 		// if err is not the file not found, so return immedietly
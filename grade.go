@@ -0,0 +1,188 @@
+package g_learning_connector
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidGradeWeights = errors.New("total bobot nilai harus lebih dari 0")
+	ErrGradeRangeNotFound  = errors.New("rentang bobot nilai yang sesuai tidak ditemukan")
+	ErrGradeWeightsTotal   = errors.New("total bobot absensi, tugas, uts, dan uas harus 100")
+)
+
+// GradeWeights: bobot (persen) setiap komponen nilai pada satu kelas.
+type GradeWeights struct {
+	Absensi int `json:"absensi"`
+	Tugas   int `json:"tugas"`
+	UTS     int `json:"uts"`
+	UAS     int `json:"uas"`
+}
+
+func (w GradeWeights) Total() int {
+	return w.Absensi + w.Tugas + w.UTS + w.UAS
+}
+
+// Validate memastikan bobot tidak negatif dan totalnya 100.
+func (w GradeWeights) Validate() error {
+	if w.Absensi < 0 || w.Tugas < 0 || w.UTS < 0 || w.UAS < 0 || w.Total() != 100 {
+		return ErrGradeWeightsTotal
+	}
+	return nil
+}
+
+// GradeComponentScores: nilai (0-100) setiap komponen, nil jika belum diisi.
+type GradeComponentScores struct {
+	Absensi *float64 `json:"absensi"`
+	Tugas   *float64 `json:"tugas"`
+	UTS     *float64 `json:"uts"`
+	UAS     *float64 `json:"uas"`
+}
+
+// GradeRange: satu baris konversi nilai angka ke nilai huruf (bobot_nilai).
+// Angkatan kosong berarti berlaku untuk semua angkatan, tanggal efektif nil berarti tidak dibatasi.
+// Rentang diperlakukan setengah terbuka [Min, Min rentang berikutnya), Max hanya informasi dari tabel sumber.
+type GradeRange struct {
+	Letter         string     `json:"nilai_huruf"`
+	Min            float64    `json:"min"`
+	Max            float64    `json:"maks"`
+	Index          float64    `json:"nilai_indeks"`
+	Angkatan       string     `json:"angkatan"`
+	EffectiveFrom  *time.Time `json:"tgl_mulai_efektif"`
+	EffectiveUntil *time.Time `json:"tgl_akhir_efektif"`
+}
+
+// GradeResult: hasil perhitungan nilai akhir satu mahasiswa.
+type GradeResult struct {
+	FinalScore float64 `json:"nilai_akhir"`
+	Letter     string  `json:"nilai_huruf"`
+	Index      float64 `json:"nilai_indeks"`
+
+	// false jika ada komponen yang belum diisi (dihitung sebagai 0)
+	Complete bool `json:"lengkap"`
+}
+
+// ComputeFinalScore menghitung nilai akhir dari komponen nilai dan bobotnya, dibulatkan 2 desimal.
+// Komponen yang belum diisi dihitung 0 dan complete bernilai false.
+func ComputeFinalScore(scores GradeComponentScores, weights GradeWeights) (score float64, complete bool, err error) {
+	total := weights.Total()
+	if total <= 0 {
+		return 0, false, ErrInvalidGradeWeights
+	}
+
+	complete = true
+	var sum float64
+
+	for _, c := range []struct {
+		score  *float64
+		weight int
+	}{
+		{scores.Absensi, weights.Absensi},
+		{scores.Tugas, weights.Tugas},
+		{scores.UTS, weights.UTS},
+		{scores.UAS, weights.UAS},
+	} {
+		if c.score == nil {
+			if c.weight > 0 {
+				complete = false
+			}
+			continue
+		}
+		sum += *c.score * float64(c.weight)
+	}
+
+	return RoundScore(sum / float64(total)), complete, nil
+}
+
+// ResolveGradeRange mencari rentang nilai huruf untuk score yang berlaku bagi angkatan pada tanggal at.
+// Rentang khusus angkatan diprioritaskan dibanding rentang yang berlaku untuk semua angkatan.
+// Yang dipilih adalah rentang dengan Min tertinggi yang tidak melebihi score, sehingga nilai di antara
+// dua rentang bilangan bulat (misalnya 79.5 pada 70-79 dan 80-100) tetap masuk ke rentang di bawahnya.
+// Tanggal efektif dibandingkan per tanggal tanpa jam, sehingga rentang tetap berlaku sepanjang hari terakhirnya.
+func ResolveGradeRange(ranges []GradeRange, angkatan string, at time.Time, score float64) (GradeRange, error) {
+	specific := make([]GradeRange, 0)
+	general := make([]GradeRange, 0)
+
+	day := dateOnly(at)
+	for _, r := range ranges {
+		if r.EffectiveFrom != nil && day.Before(dateOnly(*r.EffectiveFrom)) {
+			continue
+		}
+		if r.EffectiveUntil != nil && day.After(dateOnly(*r.EffectiveUntil)) {
+			continue
+		}
+
+		if r.Angkatan == "" {
+			general = append(general, r)
+		} else if r.Angkatan == angkatan {
+			specific = append(specific, r)
+		}
+	}
+
+	applicable := general
+	if len(specific) > 0 {
+		applicable = specific
+	}
+
+	score = RoundScore(score)
+
+	found := false
+	var result GradeRange
+	for _, r := range applicable {
+		if score >= r.Min && (!found || r.Min > result.Min) {
+			result = r
+			found = true
+		}
+	}
+
+	if !found {
+		return GradeRange{}, ErrGradeRangeNotFound
+	}
+
+	return result, nil
+}
+
+// ComputeGrade menghitung nilai akhir lalu mengkonversinya menjadi nilai huruf dan indeks.
+func ComputeGrade(scores GradeComponentScores, weights GradeWeights, ranges []GradeRange, angkatan string, at time.Time) (GradeResult, error) {
+	score, complete, err := ComputeFinalScore(scores, weights)
+	if err != nil {
+		return GradeResult{}, err
+	}
+
+	r, err := ResolveGradeRange(ranges, angkatan, at, score)
+	if err != nil {
+		return GradeResult{FinalScore: score, Complete: complete}, err
+	}
+
+	return GradeResult{
+		FinalScore: score,
+		Letter:     r.Letter,
+		Index:      r.Index,
+		Complete:   complete,
+	}, nil
+}
+
+// RoundScore membulatkan nilai menjadi 2 desimal.
+func RoundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
+
+// IsGradingLocked: nilai semester yang sudah lewat (sebelum semester aktif) dianggap final,
+// begitu juga semester yang dikunci secara manual.
+func IsGradingLocked(idSMT, activeSMT string, lockedSMT []string) bool {
+	if slices.Contains(lockedSMT, idSMT) {
+		return true
+	}
+
+	// id_smt berformat tahun + kode semester (contoh 20241) sehingga bisa dibandingkan sebagai string
+	return activeSMT != "" && len(idSMT) == len(activeSMT) && idSMT < activeSMT
+}
+
+// dateOnly mengambil tanggal kalender t pada zona waktunya sendiri, tanpa jam.
+func dateOnly(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,258 @@
+package g_learning_connector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func scorePtr(v float64) *float64 {
+	return &v
+}
+
+func datePtr(s string) *time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestComputeFinalScore(t *testing.T) {
+	tests := []struct {
+		name         string
+		scores       GradeComponentScores
+		weights      GradeWeights
+		wantScore    float64
+		wantComplete bool
+		wantErr      error
+	}{
+		{
+			name:         "semua komponen terisi",
+			scores:       GradeComponentScores{Absensi: scorePtr(100), Tugas: scorePtr(80), UTS: scorePtr(70), UAS: scorePtr(90)},
+			weights:      GradeWeights{Absensi: 10, Tugas: 20, UTS: 30, UAS: 40},
+			wantScore:    83,
+			wantComplete: true,
+		},
+		{
+			name:         "total bobot kurang dari 100 dinormalisasi",
+			scores:       GradeComponentScores{Absensi: scorePtr(100), Tugas: scorePtr(50), UTS: scorePtr(50), UAS: scorePtr(50)},
+			weights:      GradeWeights{Absensi: 10, Tugas: 10, UTS: 10, UAS: 20},
+			wantScore:    60,
+			wantComplete: true,
+		},
+		{
+			name:         "komponen kosong dihitung 0",
+			scores:       GradeComponentScores{Absensi: scorePtr(100), Tugas: scorePtr(80), UTS: scorePtr(70)},
+			weights:      GradeWeights{Absensi: 10, Tugas: 20, UTS: 30, UAS: 40},
+			wantScore:    47,
+			wantComplete: false,
+		},
+		{
+			name:         "komponen kosong dengan bobot 0 tetap lengkap",
+			scores:       GradeComponentScores{UTS: scorePtr(60), UAS: scorePtr(80)},
+			weights:      GradeWeights{UTS: 50, UAS: 50},
+			wantScore:    70,
+			wantComplete: true,
+		},
+		{
+			name:         "dibulatkan 2 desimal",
+			scores:       GradeComponentScores{Absensi: scorePtr(100), Tugas: scorePtr(100), UTS: scorePtr(0), UAS: scorePtr(0)},
+			weights:      GradeWeights{Absensi: 1, Tugas: 1, UTS: 1, UAS: 0},
+			wantScore:    66.67,
+			wantComplete: true,
+		},
+		{
+			name:    "total bobot 0",
+			scores:  GradeComponentScores{UAS: scorePtr(80)},
+			wantErr: ErrInvalidGradeWeights,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, complete, err := ComputeFinalScore(tt.scores, tt.weights)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.wantScore || complete != tt.wantComplete {
+				t.Errorf("got (%v, %v), want (%v, %v)", got, complete, tt.wantScore, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestResolveGradeRange(t *testing.T) {
+	general := []GradeRange{
+		{Letter: "E", Min: 0, Max: 39, Index: 0},
+		{Letter: "D", Min: 40, Max: 54, Index: 1},
+		{Letter: "C", Min: 55, Max: 69, Index: 2},
+		{Letter: "B", Min: 70, Max: 79, Index: 3},
+		{Letter: "A", Min: 80, Max: 100, Index: 4},
+	}
+	at := *datePtr("2024-09-01")
+
+	tests := []struct {
+		name       string
+		ranges     []GradeRange
+		angkatan   string
+		at         time.Time
+		score      float64
+		wantLetter string
+		wantErr    error
+	}{
+		{name: "batas bawah rentang", ranges: general, at: at, score: 80, wantLetter: "A"},
+		{name: "batas atas rentang", ranges: general, at: at, score: 79, wantLetter: "B"},
+		{name: "di antara dua rentang bilangan bulat", ranges: general, at: at, score: 79.5, wantLetter: "B"},
+		{name: "dibulatkan sebelum dicocokkan", ranges: general, at: at, score: 79.999, wantLetter: "A"},
+		{name: "nilai maksimum", ranges: general, at: at, score: 100, wantLetter: "A"},
+		{name: "nilai 0", ranges: general, at: at, score: 0, wantLetter: "E"},
+		{
+			name: "rentang tumpang tindih memilih Min tertinggi",
+			ranges: []GradeRange{
+				{Letter: "B", Min: 70, Max: 85},
+				{Letter: "A", Min: 80, Max: 100},
+			},
+			at:         at,
+			score:      82,
+			wantLetter: "A",
+		},
+		{
+			name:     "rentang angkatan diprioritaskan",
+			ranges:   append([]GradeRange{{Letter: "A", Min: 85, Max: 100, Angkatan: "2023"}, {Letter: "B", Min: 0, Max: 84, Angkatan: "2023"}}, general...),
+			angkatan: "2023",
+			at:       at,
+			score:    82,
+			// rentang umum tidak dipakai sama sekali jika angkatan memiliki rentang sendiri
+			wantLetter: "B",
+		},
+		{
+			name:       "angkatan lain memakai rentang umum",
+			ranges:     append([]GradeRange{{Letter: "B", Min: 0, Max: 100, Angkatan: "2023"}}, general...),
+			angkatan:   "2022",
+			at:         at,
+			score:      82,
+			wantLetter: "A",
+		},
+		{
+			name: "tanggal efektif",
+			ranges: []GradeRange{
+				{Letter: "A-lama", Min: 80, Max: 100, EffectiveUntil: datePtr("2023-12-31")},
+				{Letter: "A-baru", Min: 80, Max: 100, EffectiveFrom: datePtr("2024-01-01")},
+			},
+			at:         at,
+			score:      90,
+			wantLetter: "A-baru",
+		},
+		{
+			name:       "batas tanggal efektif inklusif",
+			ranges:     []GradeRange{{Letter: "A", Min: 80, Max: 100, EffectiveFrom: datePtr("2024-09-01"), EffectiveUntil: datePtr("2024-09-01")}},
+			at:         at,
+			score:      90,
+			wantLetter: "A",
+		},
+		{
+			name:       "hari terakhir tanggal efektif setelah tengah malam",
+			ranges:     []GradeRange{{Letter: "A", Min: 80, Max: 100, EffectiveUntil: datePtr("2024-09-01")}},
+			at:         time.Date(2024, time.September, 1, 15, 30, 0, 0, time.Local),
+			score:      90,
+			wantLetter: "A",
+		},
+		{
+			name:       "hari pertama tanggal efektif setelah tengah malam",
+			ranges:     []GradeRange{{Letter: "A", Min: 80, Max: 100, EffectiveFrom: datePtr("2024-09-01")}},
+			at:         time.Date(2024, time.September, 1, 0, 0, 1, 0, time.Local),
+			score:      90,
+			wantLetter: "A",
+		},
+		{
+			name:    "sebelum tanggal efektif",
+			ranges:  []GradeRange{{Letter: "A", Min: 80, Max: 100, EffectiveFrom: datePtr("2024-09-02")}},
+			at:      at,
+			score:   90,
+			wantErr: ErrGradeRangeNotFound,
+		},
+		{
+			name:    "setelah tanggal efektif",
+			ranges:  []GradeRange{{Letter: "A", Min: 80, Max: 100, EffectiveUntil: datePtr("2024-08-31")}},
+			at:      at,
+			score:   90,
+			wantErr: ErrGradeRangeNotFound,
+		},
+		{
+			name:    "di bawah rentang terendah",
+			ranges:  []GradeRange{{Letter: "A", Min: 80, Max: 100}},
+			at:      at,
+			score:   79.99,
+			wantErr: ErrGradeRangeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveGradeRange(tt.ranges, tt.angkatan, tt.at, tt.score)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got.Letter != tt.wantLetter {
+				t.Errorf("letter = %q, want %q", got.Letter, tt.wantLetter)
+			}
+		})
+	}
+}
+
+func TestComputeGrade(t *testing.T) {
+	ranges := []GradeRange{
+		{Letter: "C", Min: 0, Max: 69, Index: 2},
+		{Letter: "B", Min: 70, Max: 79, Index: 3},
+		{Letter: "A", Min: 80, Max: 100, Index: 4},
+	}
+	weights := GradeWeights{Absensi: 10, Tugas: 20, UTS: 30, UAS: 40}
+	at := *datePtr("2024-09-01")
+
+	tests := []struct {
+		name    string
+		scores  GradeComponentScores
+		ranges  []GradeRange
+		want    GradeResult
+		wantErr error
+	}{
+		{
+			name:   "nilai lengkap",
+			scores: GradeComponentScores{Absensi: scorePtr(100), Tugas: scorePtr(80), UTS: scorePtr(70), UAS: scorePtr(90)},
+			ranges: ranges,
+			want:   GradeResult{FinalScore: 83, Letter: "A", Index: 4, Complete: true},
+		},
+		{
+			name:   "nilai akhir di antara rentang",
+			scores: GradeComponentScores{Absensi: scorePtr(100), Tugas: scorePtr(80), UTS: scorePtr(75), UAS: scorePtr(75.5)},
+			ranges: ranges,
+			want:   GradeResult{FinalScore: 78.7, Letter: "B", Index: 3, Complete: true},
+		},
+		{
+			name:   "komponen belum lengkap",
+			scores: GradeComponentScores{Absensi: scorePtr(100), UAS: scorePtr(100)},
+			ranges: ranges,
+			want:   GradeResult{FinalScore: 50, Letter: "C", Index: 2, Complete: false},
+		},
+		{
+			name:    "rentang tidak ditemukan tetap mengembalikan nilai akhir",
+			scores:  GradeComponentScores{Absensi: scorePtr(100), Tugas: scorePtr(100), UTS: scorePtr(100), UAS: scorePtr(100)},
+			want:    GradeResult{FinalScore: 100, Complete: true},
+			wantErr: ErrGradeRangeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ComputeGrade(tt.scores, weights, tt.ranges, "", at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package g_learning_connector
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+const (
+	TipeKuliahTeori          = "teori"
+	TipeKuliahPraktikum      = "praktikum"
+	TipeKuliahTeoriPraktikum = "teori_praktikum"
+
+	TipePenilaianDetail = "detail"
+	TipePenilaianAngka  = "angka"
+)
+
+var (
+	ErrInvalidGradingScheme   = errors.New("format unsur nilai tidak valid")
+	ErrGradingSchemeMismatch  = errors.New("unsur nilai tidak sesuai dengan skema")
+	ErrGradingComponentsTotal = errors.New("total bobot unsur nilai harus 100")
+)
+
+// GradingComponent: satu unsur penilaian beserta bobotnya (persen).
+// Unsur bisa memiliki sub unsur, misalnya "Tugas" yang terdiri dari beberapa tugas.
+type GradingComponent struct {
+	Kode     string             `json:"kode"`
+	Nama     string             `json:"nama"`
+	Bobot    float64            `json:"bobot"`
+	Komponen []GradingComponent `json:"komponen,omitempty"`
+}
+
+// key yang dikenali pada json unsur, disimpan berbeda-beda tergantung versi aplikasi
+var (
+	gradingComponentNameKeys   = []string{"nama", "name", "unsur", "label"}
+	gradingComponentCodeKeys   = []string{"kode", "code", "key", "id"}
+	gradingComponentWeightKeys = []string{"bobot", "weight", "persentase", "persen", "nilai"}
+	gradingComponentChildKeys  = []string{"komponen", "sub_unsur", "children", "detail"}
+)
+
+// ParseGradingComponents mengubah kolom unsur_nilai.unsur menjadi daftar unsur penilaian.
+// Format yang diterima:
+//   - array object, contoh [{"nama": "UTS", "bobot": 30}, ...]
+//   - object nama ke bobot, contoh {"UTS": 30, "UAS": 40}
+func ParseGradingComponents(raw string) ([]GradingComponent, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return []GradingComponent{}, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, errors.Wrap(ErrInvalidGradingScheme, err.Error())
+	}
+
+	return parseGradingComponents(value)
+}
+
+// TotalGradingWeight menjumlahkan bobot unsur pada level teratas.
+func TotalGradingWeight(components []GradingComponent) float64 {
+	var total float64
+	for _, c := range components {
+		total += c.Bobot
+	}
+	return total
+}
+
+// ValidateGradingComponents memastikan components hanya berisi unsur yang ada pada scheme,
+// setiap unsur pada scheme terisi tepat satu kali, dan total bobotnya 100.
+// Unsur dicocokkan berdasarkan kode tanpa membedakan huruf besar/kecil.
+func ValidateGradingComponents(components, scheme []GradingComponent) error {
+	expected := make(map[string]struct{}, len(scheme))
+	for _, c := range scheme {
+		expected[strings.ToLower(c.Kode)] = struct{}{}
+	}
+
+	seen := make(map[string]struct{}, len(components))
+	for _, c := range components {
+		kode := strings.ToLower(c.Kode)
+		if _, ok := expected[kode]; !ok {
+			return errors.Wrapf(ErrGradingSchemeMismatch, "unsur %s tidak ada pada skema", c.Kode)
+		}
+		if _, ok := seen[kode]; ok {
+			return errors.Wrapf(ErrGradingSchemeMismatch, "unsur %s diisi lebih dari sekali", c.Kode)
+		}
+		if c.Bobot < 0 {
+			return errors.Wrapf(ErrGradingSchemeMismatch, "bobot unsur %s tidak boleh negatif", c.Kode)
+		}
+		seen[kode] = struct{}{}
+	}
+
+	for _, c := range scheme {
+		if _, ok := seen[strings.ToLower(c.Kode)]; !ok {
+			return errors.Wrapf(ErrGradingSchemeMismatch, "unsur %s belum diisi", c.Kode)
+		}
+	}
+
+	if RoundScore(TotalGradingWeight(components)) != 100 {
+		return ErrGradingComponentsTotal
+	}
+
+	return nil
+}
+
+// ResolveTipeKuliah menentukan tipe kuliah dari komposisi sks mata kuliah.
+func ResolveTipeKuliah(sksTatapMuka, sksPraktikum float64) string {
+	switch {
+	case sksPraktikum > 0 && sksTatapMuka > 0:
+		return TipeKuliahTeoriPraktikum
+	case sksPraktikum > 0:
+		return TipeKuliahPraktikum
+	default:
+		return TipeKuliahTeori
+	}
+}
+
+func parseGradingComponents(value interface{}) ([]GradingComponent, error) {
+	components := make([]GradingComponent, 0)
+
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, errors.Wrapf(ErrInvalidGradingScheme, "unsur ke-%d bukan object", i+1)
+			}
+
+			component, err := parseGradingComponent(obj)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unsur ke-%d", i+1)
+			}
+			components = append(components, component)
+		}
+	case map[string]interface{}:
+		for name, item := range v {
+			component := GradingComponent{Kode: name, Nama: name}
+
+			if obj, ok := item.(map[string]interface{}); ok {
+				parsed, err := parseGradingComponent(obj)
+				if err != nil {
+					return nil, errors.Wrapf(err, "unsur %s", name)
+				}
+				if parsed.Nama == "" {
+					parsed.Nama = name
+				}
+				if parsed.Kode == "" {
+					parsed.Kode = name
+				}
+				component = parsed
+			} else {
+				weight, err := parseGradingWeight(item)
+				if err != nil {
+					return nil, errors.Wrapf(err, "unsur %s", name)
+				}
+				component.Bobot = weight
+			}
+
+			components = append(components, component)
+		}
+		// urutan key pada map tidak stabil
+		sort.Slice(components, func(i, j int) bool {
+			return components[i].Nama < components[j].Nama
+		})
+	default:
+		return nil, ErrInvalidGradingScheme
+	}
+
+	return components, nil
+}
+
+func parseGradingComponent(obj map[string]interface{}) (GradingComponent, error) {
+	var component GradingComponent
+
+	component.Nama = firstStringValue(obj, gradingComponentNameKeys)
+	component.Kode = firstStringValue(obj, gradingComponentCodeKeys)
+	if component.Kode == "" {
+		component.Kode = component.Nama
+	}
+
+	for _, key := range gradingComponentWeightKeys {
+		if raw, ok := obj[key]; ok {
+			weight, err := parseGradingWeight(raw)
+			if err != nil {
+				return GradingComponent{}, err
+			}
+			component.Bobot = weight
+			break
+		}
+	}
+
+	for _, key := range gradingComponentChildKeys {
+		raw, ok := obj[key]
+		if !ok || raw == nil {
+			continue
+		}
+
+		children, err := parseGradingComponents(raw)
+		if err != nil {
+			return GradingComponent{}, err
+		}
+		component.Komponen = children
+		break
+	}
+
+	return component, nil
+}
+
+func parseGradingWeight(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		weight, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "%"), 64)
+		if err != nil {
+			return 0, errors.Wrapf(ErrInvalidGradingScheme, "bobot %q bukan angka", v)
+		}
+		return weight, nil
+	case nil:
+		return 0, nil
+	default:
+		return 0, errors.Wrap(ErrInvalidGradingScheme, "bobot bukan angka")
+	}
+}
+
+func firstStringValue(obj map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		switch v := obj[key].(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
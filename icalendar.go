@@ -0,0 +1,111 @@
+package g_learning_connector
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	calendarProdID = "-//G-Learning Connector//Jadwal Perkuliahan//ID"
+
+	// baris iCalendar maksimal 75 octet, sisanya dilanjutkan pada baris berikutnya yang diawali spasi (RFC 5545 3.1)
+	calendarLineLimit = 75
+
+	calendarTimeLayout = "20060102T150405Z"
+)
+
+// CalendarEvent adalah satu VEVENT. RecurUntil diisi untuk jadwal mingguan (RRULE:FREQ=WEEKLY),
+// kosong untuk sesi yang hanya terjadi sekali.
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	RecurUntil  *time.Time
+}
+
+// WriteCalendar menulis VCALENDAR berisi events. Semua waktu ditulis dalam UTC
+// sehingga tidak memerlukan VTIMEZONE.
+func WriteCalendar(w io.Writer, name string, events []CalendarEvent, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeCalendarLine(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", calendarProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeCalendarText(name))
+
+	stamp := now.UTC().Format(calendarTimeLayout)
+	for _, event := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", stamp)
+		line("DTSTART", event.Start.UTC().Format(calendarTimeLayout))
+		line("DTEND", event.End.UTC().Format(calendarTimeLayout))
+		if event.RecurUntil != nil {
+			line("RRULE", "FREQ=WEEKLY;UNTIL="+event.RecurUntil.UTC().Format(calendarTimeLayout))
+		}
+		line("SUMMARY", escapeCalendarText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeCalendarText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escapeCalendarText(event.Location))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// FirstWeekday mengembalikan tanggal pertama mulai dari date yang jatuh pada hari (0 = Minggu sampai 6 = Sabtu).
+func FirstWeekday(date time.Time, hari int) time.Time {
+	diff := (hari - int(date.Weekday()) + 7) % 7
+	return date.AddDate(0, 0, diff)
+}
+
+func escapeCalendarText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeCalendarLine menulis satu content line dengan CRLF dan memotongnya per 75 octet
+// tanpa memotong karakter UTF-8 di tengah.
+func writeCalendarLine(w *bufio.Writer, s string) {
+	limit := calendarLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// baris lanjutan diawali spasi yang ikut dihitung
+		limit = calendarLineLimit - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
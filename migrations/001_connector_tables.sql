@@ -0,0 +1,50 @@
+-- Tabel milik connector. Jalankan sekali dengan user database yang memiliki hak DDL, misalnya:
+--   mysql -h <DB_HOST> -P <DB_PORT> -u <user> -p <DB_DATABASE> < migrations/001_connector_tables.sql
+-- Connector tidak membuat tabel ini sendiri karena user aplikasi pada database SIAKAD umumnya tidak memiliki hak DDL.
+
+CREATE TABLE IF NOT EXISTS `connector_deletion_snapshot` (
+  `resource` varchar(64) NOT NULL,
+  `record_id` varchar(191) NOT NULL,
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT NULL,
+  `updated_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`resource`, `record_id`),
+  KEY `idx_connector_deletion_snapshot_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `connector_audit_log` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `resource` varchar(64) NOT NULL,
+  `record_id` varchar(191) NOT NULL,
+  `action` varchar(32) NOT NULL,
+  `before` longtext NULL,
+  `after` longtext NULL,
+  `api_key` varchar(32) NOT NULL,
+  `instansi` varchar(16) NOT NULL,
+  `ip_address` varchar(64) NOT NULL,
+  `created_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_audit_resource` (`resource`, `record_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `connector_idempotency_key` (
+  `key` varchar(64) NOT NULL,
+  `request_hash` varchar(64) NOT NULL,
+  `status_code` bigint NOT NULL,
+  `response` longblob NULL,
+  `created_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`key`),
+  KEY `idx_connector_idempotency_key_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `connector_calendar_token` (
+  `id` varchar(32) NOT NULL,
+  `jenis` varchar(16) NOT NULL,
+  `subject_id` varchar(191) NOT NULL,
+  `instansi` varchar(16) NOT NULL,
+  `api_key` varchar(32) NOT NULL,
+  `created_at` datetime(3) NULL DEFAULT NULL,
+  `revoked_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_calendar_token_subject` (`jenis`, `subject_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,9 @@
+-- Unique index id_kls pada kelaskuliah_bobot_nilai agar PUT /classes/:id/grading-weights bisa memakai upsert
+-- dan request bersamaan tidak membuat dua baris bobot untuk satu kelas.
+-- Baris duplikat yang sudah ada dihapus terlebih dahulu, yang disisakan adalah baris dengan id terbesar (paling baru).
+
+DELETE older FROM `kelaskuliah_bobot_nilai` older
+JOIN `kelaskuliah_bobot_nilai` newer ON newer.`id_kls` = older.`id_kls` AND newer.`id` > older.`id`;
+
+ALTER TABLE `kelaskuliah_bobot_nilai`
+  ADD UNIQUE INDEX `uniq_kelaskuliah_bobot_nilai_id_kls` (`id_kls`);
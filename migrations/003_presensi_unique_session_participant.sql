@@ -0,0 +1,21 @@
+-- Unique index sesi + peserta pada tabel presensi agar POST /sessions/:id/attendance bisa memakai upsert
+-- dan request bersamaan tidak membuat dua baris presensi untuk peserta yang sama pada satu sesi.
+-- Tabel presensi milik SIAKAD, sehingga baris ganda tidak dihapus otomatis: SELECT di bawah menampilkan
+-- baris ganda (jika ada) dan ALTER TABLE akan gagal dengan "Duplicate entry" sampai operator memilih
+-- baris yang disimpan lalu menjalankan ulang ALTER TABLE yang gagal.
+
+SELECT `id_jadwal_perkuliahan`, `id_pd`, COUNT(*) AS jumlah, GROUP_CONCAT(`id` ORDER BY `id`) AS id_presensi
+FROM `presensi_mahasiswa`
+GROUP BY `id_jadwal_perkuliahan`, `id_pd`
+HAVING COUNT(*) > 1;
+
+ALTER TABLE `presensi_mahasiswa`
+  ADD UNIQUE INDEX `uniq_presensi_mahasiswa_sesi_pd` (`id_jadwal_perkuliahan`, `id_pd`);
+
+SELECT `id_jadwal_perkuliahan`, `id_ptk`, COUNT(*) AS jumlah, GROUP_CONCAT(`id` ORDER BY `id`) AS id_presensi
+FROM `presensi_dosen`
+GROUP BY `id_jadwal_perkuliahan`, `id_ptk`
+HAVING COUNT(*) > 1;
+
+ALTER TABLE `presensi_dosen`
+  ADD UNIQUE INDEX `uniq_presensi_dosen_sesi_ptk` (`id_jadwal_perkuliahan`, `id_ptk`);
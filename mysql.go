@@ -8,13 +8,19 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultGroupConcatMaxLen menggantikan default MySQL 1024 byte yang terlalu kecil untuk list kelas mahasiswa.
+const DefaultGroupConcatMaxLen = 1 << 20
+
 func NewMySQLDatabase(config *Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	// parameter DSN yang tidak dikenal driver dipasang sebagai session variable (SET ...) di setiap koneksi baru,
+	// sehingga group_concat_max_len berlaku untuk semua koneksi pada pool
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&group_concat_max_len=%d",
 		config.DBUsername,
 		config.DBPassword,
 		config.DBHost,
 		config.DBPort,
 		config.DBDatabase,
+		config.DBGroupConcatMaxLen,
 	)
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
@@ -39,3 +45,9 @@ func NewMySQLDatabase(config *Config) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// GroupConcatTruncated: MySQL memotong hasil GROUP_CONCAT tepat pada group_concat_max_len byte tanpa error,
+// sehingga hasil sepanjang batas tersebut dianggap terpotong.
+func GroupConcatTruncated(value string, maxLen int) bool {
+	return maxLen > 0 && len(value) >= maxLen
+}
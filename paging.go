@@ -94,3 +94,17 @@ func NewPageInfo(
 		Style2:                 style2,
 	}, nil
 }
+
+// Paginate mengambil potongan items sesuai offset dan limit, untuk data yang dipaginasi setelah diolah di aplikasi.
+func Paginate[T any](items []T, offset, limit int64) []T {
+	if offset < 0 || offset >= int64(len(items)) {
+		return items[:0]
+	}
+
+	end := offset + limit
+	if limit <= 0 || end > int64(len(items)) {
+		end = int64(len(items))
+	}
+
+	return items[offset:end]
+}
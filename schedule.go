@@ -0,0 +1,50 @@
+package g_learning_connector
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	LangID = "id"
+	LangEN = "en"
+)
+
+var ErrInvalidClock = errors.New("jam harus menggunakan format HH:MM atau HH:MM:SS")
+
+// dayNames mengikuti nilai kolom jadwal.hari, 0 = Minggu sampai 6 = Sabtu.
+var dayNames = map[string][7]string{
+	LangID: {"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"},
+	LangEN: {"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+}
+
+// NamaHari mengembalikan nama hari dalam bahasa Indonesia untuk nilai jadwal.hari, "Unknown" jika di luar 0-6
+// (sama dengan CASE jadwal.hari pada query list kelas).
+func NamaHari(hari int) string {
+	return DayName(hari, LangID)
+}
+
+// DayName mengembalikan nama hari dalam bahasa lang (id atau en, selain itu id).
+func DayName(hari int, lang string) string {
+	names, ok := dayNames[lang]
+	if !ok {
+		names = dayNames[LangID]
+	}
+
+	if hari < 0 || hari >= len(names) {
+		return "Unknown"
+	}
+	return names[hari]
+}
+
+// NormalizeClock mengubah jam HH:MM atau HH:MM:SS menjadi HH:MM:SS (format kolom TIME),
+// sehingga jam bisa dibandingkan langsung sebagai string.
+func NormalizeClock(clock string) (string, error) {
+	for _, layout := range []string{time.TimeOnly, "15:04"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return t.Format(time.TimeOnly), nil
+		}
+	}
+	return "", errors.Wrapf(ErrInvalidClock, "jam %q", clock)
+}
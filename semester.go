@@ -0,0 +1,85 @@
+package g_learning_connector
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TermGanjil = 1
+	TermGenap  = 2
+	TermPendek = 3
+)
+
+var ErrInvalidSemesterID = errors.New("id semester harus berformat TTTTS, contoh 20241")
+
+// termNames mengikuti digit terakhir id_smt, 1 = ganjil, 2 = genap, 3 = pendek.
+var termNames = map[string][4]string{
+	LangID: {"", "Ganjil", "Genap", "Pendek"},
+	LangEN: {"", "Odd", "Even", "Short"},
+}
+
+// SemesterID adalah id_smt yang sudah diurai: Year adalah tahun awal tahun ajaran, Term jenis semesternya.
+type SemesterID struct {
+	Year int
+	Term int
+}
+
+// ParseSemesterID mengurai id_smt berformat TTTTS, misalnya 20241 menjadi tahun ajaran 2024/2025 semester ganjil.
+func ParseSemesterID(id string) (SemesterID, error) {
+	if len(id) != 5 {
+		return SemesterID{}, errors.Wrapf(ErrInvalidSemesterID, "semester %q", id)
+	}
+
+	year, err := strconv.Atoi(id[:4])
+	if err != nil || year <= 0 {
+		return SemesterID{}, errors.Wrapf(ErrInvalidSemesterID, "semester %q", id)
+	}
+
+	term := int(id[4] - '0')
+	if term < TermGanjil || term > TermPendek {
+		return SemesterID{}, errors.Wrapf(ErrInvalidSemesterID, "semester %q", id)
+	}
+
+	return SemesterID{Year: year, Term: term}, nil
+}
+
+// AcademicYear mengembalikan tahun ajaran, misalnya 2024/2025.
+func (s SemesterID) AcademicYear() string {
+	return fmt.Sprintf("%d/%d", s.Year, s.Year+1)
+}
+
+// TermName mengembalikan nama jenis semester dalam bahasa lang (id atau en, selain itu id).
+func TermName(term int, lang string) string {
+	names, ok := termNames[lang]
+	if !ok {
+		names = termNames[LangID]
+	}
+
+	if term < TermGanjil || term > TermPendek {
+		return "Unknown"
+	}
+	return names[term]
+}
+
+// DefaultSemesterPeriod memperkirakan tanggal mulai dan selesai semester dari id_smt (tahun ajaran + 1 ganjil,
+// 2 genap, 3 pendek), dipakai jika tanggal semester tidak diisi. Semester 20241 adalah ganjil 2024/2025.
+func DefaultSemesterPeriod(id string, loc *time.Location) (time.Time, time.Time, error) {
+	semester, err := ParseSemesterID(id)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	year := semester.Year
+	switch semester.Term {
+	case TermGanjil:
+		return time.Date(year, time.September, 1, 0, 0, 0, 0, loc), time.Date(year+1, time.January, 31, 0, 0, 0, 0, loc), nil
+	case TermGenap:
+		return time.Date(year+1, time.February, 1, 0, 0, 0, 0, loc), time.Date(year+1, time.June, 30, 0, 0, 0, 0, loc), nil
+	default:
+		return time.Date(year+1, time.July, 1, 0, 0, 0, 0, loc), time.Date(year+1, time.August, 31, 0, 0, 0, 0, loc), nil
+	}
+}
@@ -0,0 +1,95 @@
+package g_learning_connector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	SessionStatusTerjadwal = "terjadwal"
+	SessionStatusDimulai   = "dimulai"
+	SessionStatusSelesai   = "selesai"
+
+	// sesi boleh dimulai paling cepat SessionStartEarly sebelum jam_mulai
+	SessionStartEarly = 30 * time.Minute
+)
+
+var (
+	ErrInvalidSessionTransition = errors.New("perubahan status sesi tidak valid")
+	ErrSessionOutsideWindow     = errors.New("sesi berada di luar rentang waktu yang diizinkan")
+	ErrInvalidSessionTime       = errors.New("tanggal atau jam sesi tidak valid")
+)
+
+// sessionTransitions: status sesi hanya bisa maju, terjadwal -> dimulai -> selesai.
+var sessionTransitions = map[string]string{
+	SessionStatusTerjadwal: SessionStatusDimulai,
+	SessionStatusDimulai:   SessionStatusSelesai,
+}
+
+// SessionWindow adalah waktu mulai dan selesai sesi menurut jadwal.
+type SessionWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// NewSessionWindow menggabungkan tanggal dengan jam_mulai dan jam_selesai (format HH:MM atau HH:MM:SS).
+func NewSessionWindow(tanggal time.Time, jamMulai, jamSelesai string, loc *time.Location) (SessionWindow, error) {
+	start, err := combineDateClock(tanggal, jamMulai, loc)
+	if err != nil {
+		return SessionWindow{}, err
+	}
+
+	end, err := combineDateClock(tanggal, jamSelesai, loc)
+	if err != nil {
+		return SessionWindow{}, err
+	}
+
+	// sesi yang melewati tengah malam
+	if end.Before(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	return SessionWindow{Start: start, End: end}, nil
+}
+
+// ValidateSessionTransition memastikan status berpindah sesuai urutan.
+func ValidateSessionTransition(from, to string) error {
+	if sessionTransitions[from] != to {
+		return errors.Wrapf(ErrInvalidSessionTransition, "%s -> %s", from, to)
+	}
+	return nil
+}
+
+// CanStart: sesi bisa dimulai sejak SessionStartEarly sebelum jam_mulai sampai jam_selesai.
+func (w SessionWindow) CanStart(now time.Time) error {
+	if now.Before(w.Start.Add(-SessionStartEarly)) || now.After(w.End) {
+		return errors.Wrapf(ErrSessionOutsideWindow, "sesi bisa dimulai antara %s dan %s",
+			w.Start.Add(-SessionStartEarly).Format(time.DateTime), w.End.Format(time.DateTime))
+	}
+	return nil
+}
+
+// CanEnd: sesi tidak bisa diakhiri sebelum jam_mulai. Sesi yang lupa diakhiri tetap bisa diakhiri setelah jam_selesai.
+func (w SessionWindow) CanEnd(now time.Time) error {
+	if now.Before(w.Start) {
+		return errors.Wrapf(ErrSessionOutsideWindow, "sesi bisa diakhiri setelah %s", w.Start.Format(time.DateTime))
+	}
+	return nil
+}
+
+func combineDateClock(date time.Time, clock string, loc *time.Location) (time.Time, error) {
+	var hour, minute, second int
+	if _, err := fmt.Sscanf(clock, "%d:%d:%d", &hour, &minute, &second); err != nil {
+		if _, err := fmt.Sscanf(clock, "%d:%d", &hour, &minute); err != nil {
+			return time.Time{}, errors.Wrapf(ErrInvalidSessionTime, "jam %q", clock)
+		}
+	}
+
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 || second < 0 || second > 59 {
+		return time.Time{}, errors.Wrapf(ErrInvalidSessionTime, "jam %q", clock)
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, second, 0, loc), nil
+}
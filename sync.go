@@ -0,0 +1,63 @@
+package g_learning_connector
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidUpdatedSince = errors.New("updated_since harus menggunakan format RFC3339, contoh: 2024-08-01T00:00:00+07:00")
+
+// SyncFilter: parameter untuk sinkronisasi incremental (hanya data yang berubah).
+// Client mengirimkan sync_token dari response sebelumnya sebagai updated_since.
+type SyncFilter struct {
+	UpdatedSince string `json:"updated_since" form:"updated_since" query:"updated_since"` // RFC3339 timestamp
+	// sync=true meminta sync_token tanpa updated_since, dipakai untuk sinkronisasi penuh yang pertama
+	Sync bool `json:"sync" form:"sync" query:"sync"`
+}
+
+func (s *SyncFilter) HasUpdatedSince() bool {
+	return s.UpdatedSince != ""
+}
+
+// WantsSyncToken bernilai true jika client sedang sinkronisasi, sehingga response perlu membawa sync_token.
+func (s *SyncFilter) WantsSyncToken() bool {
+	return s.Sync || s.HasUpdatedSince()
+}
+
+func (s *SyncFilter) GetUpdatedSince() (time.Time, error) {
+	since, err := time.Parse(time.RFC3339, s.UpdatedSince)
+	if err != nil {
+		return time.Time{}, ErrInvalidUpdatedSince
+	}
+
+	return since, nil
+}
+
+// SyncInfo: informasi sinkronisasi yang dikembalikan bersama data list.
+type SyncInfo struct {
+	// waktu yang dikirim client sebagai updated_since (null jika tidak dikirim)
+	UpdatedSince *time.Time `json:"updated_since"`
+
+	// high-water mark, kirim kembali sebagai updated_since pada sinkronisasi berikutnya
+	// (hanya diisi jika client mengirim updated_since atau sync=true)
+	SyncToken string `json:"sync_token,omitempty"`
+
+	// true jika data yang dikembalikan adalah snapshot penuh, bukan delta
+	// (updated_since tidak dikirim atau tabel sumber tidak memiliki kolom timestamp)
+	FullSnapshot bool `json:"full_snapshot"`
+}
+
+// NewSyncInfo membuat objek SyncInfo baru. syncedAt adalah waktu database
+// saat query dijalankan sehingga perubahan setelahnya akan ikut pada sinkronisasi berikutnya.
+// syncedAt kosong (zero) berarti sync_token tidak dikirim.
+func NewSyncInfo(updatedSince *time.Time, syncedAt time.Time, fullSnapshot bool) *SyncInfo {
+	info := &SyncInfo{
+		UpdatedSince: updatedSince,
+		FullSnapshot: fullSnapshot,
+	}
+	if !syncedAt.IsZero() {
+		info.SyncToken = syncedAt.Format(time.RFC3339)
+	}
+	return info
+}